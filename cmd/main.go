@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/namsral/flag"
 	"github.com/pkg/errors"
-	"gopkg.in/yaml.v3"
+	"github.com/spf13/afero"
 
 	"github.com/ppwfx/shellpane/internal/bootstrap"
+	"github.com/ppwfx/shellpane/internal/business"
 	"github.com/ppwfx/shellpane/internal/communication"
+	"github.com/ppwfx/shellpane/internal/domain"
 )
 
 func getConfig(args []string) (bootstrap.ContainerConfig, error) {
@@ -32,10 +37,19 @@ func getConfig(args []string) (bootstrap.ContainerConfig, error) {
 	fs.StringVar(&conf.Communication.Router.BasicAuth.Username, "basic-auth-username", "", "optional: specify a basic auth username")
 	fs.StringVar(&conf.Communication.Router.BasicAuth.Password, "basic-auth-password", "", "optional: specify a basic auth password")
 	fs.StringVar(&conf.Communication.UserIDHeader, "user-id-header", "", "optional: name of user id header; implicitly activates permissions")
+	fs.StringVar(&conf.Communication.GroupsHeader, "groups-header", "", "optional: name of a header with the comma separated groups of the user, e.g. X-Forwarded-Groups; it must be set by a trusted proxy and requires the user id header")
+	fs.StringVar(&conf.Communication.JWT.JWKS, "jwt-jwks", "", "optional: path or url of a json web key set to verify bearer tokens with; activates jwt authentication and permissions")
+	fs.StringVar(&conf.Communication.JWT.KeyFile, "jwt-key-file", "", "optional: path of a pem encoded public key to verify bearer tokens with, if no jwks is set; activates jwt authentication and permissions")
+	fs.StringVar(&conf.Communication.JWT.Issuer, "jwt-issuer", "", "optional: expected iss claim of bearer tokens")
+	fs.StringVar(&conf.Communication.JWT.Audience, "jwt-audience", "", "optional: expected aud claim of bearer tokens")
+	fs.StringVar(&conf.Communication.JWT.UserIDClaim, "jwt-user-id-claim", "sub", "claim of bearer tokens that holds the user id")
+	fs.StringVar(&conf.Communication.JWT.GroupsClaim, "jwt-groups-claim", "", "optional: claim of bearer tokens that holds the groups of the user, e.g. groups")
 	fs.StringVar(&conf.Communication.DefaultUserID, "default-user-id", "", "optional: specify a default user id, if the user id header is not set or the user id header is empty")
 	fs.StringVar(&conf.Communication.CorsOrigin, "cors-origin", "", "optional: specify a cors origin")
 
-	fs.StringVar(&conf.ShellpaneYAMLPath, "shellpane-yaml-path", "", "path to specs yaml")
+	fs.StringVar(&conf.ShellpaneYAMLPath, "shellpane-yaml-path", "", "path to specs yaml, a directory of yaml files or a glob")
+	fs.DurationVar(&conf.ShellpaneYAMLWatchInterval, "shellpane-yaml-watch-interval", 2*time.Second, "how often to check the specs yaml for changes to reload it; 0 disables watching, SIGHUP always reloads")
+	fs.StringVar(&conf.StorePath, "store-path", "", "optional: bbolt database file to persist configs and runs in; the specs yaml is imported into it, without specs yaml the stored configs are served read only and must not use secrets")
 	var specsYAML string
 	fs.StringVar(&specsYAML, "shellpane-yaml", "", "specs as yaml")
 
@@ -45,16 +59,86 @@ func getConfig(args []string) (bootstrap.ContainerConfig, error) {
 	}
 
 	if specsYAML != "" {
-		err = yaml.Unmarshal([]byte(specsYAML), &conf.ShellpaneConfig)
+		config, err := bootstrap.UnmarshalShellpaneConfig(afero.NewOsFs(), []byte(specsYAML))
 		if err != nil {
 			return conf, errors.Wrapf(err, "failed to unmarshal shellpane-yaml=%v", specsYAML)
 		}
+
+		conf.ShellpaneConfig = &config
 	}
 
 	return conf, nil
 }
 
+// runCheck loads the config of the validate and lint subcommands, reports its errors and,
+// when linting, its warnings and returns the exit code
+func runCheck(subcommand string, args []string) int {
+	fs := flag.NewFlagSet(subcommand, flag.ContinueOnError)
+
+	var path string
+	fs.StringVar(&path, "shellpane-yaml-path", "", "path to specs yaml, a directory of yaml files or a glob")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if path == "" {
+		path = fs.Arg(0)
+	}
+
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "usage: shellpane %v [-shellpane-yaml-path] <path>\n", subcommand)
+
+		return 2
+	}
+
+	config, err := bootstrap.LoadShellpaneConfig(afero.NewOsFs(), path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 1
+	}
+
+	if subcommand == "lint" {
+		for _, w := range bootstrap.LintShellpaneConfig(config) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", w)
+		}
+	}
+
+	fmt.Printf("%v is valid\n", path)
+
+	return 0
+}
+
 func main() {
+	if len(os.Args) > 1 && (os.Args[1] == "validate" || os.Args[1] == "lint") {
+		os.Exit(runCheck(os.Args[1], os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		schema, err := bootstrap.ShellpaneConfigSchema()
+		if err != nil {
+			log.Fatalf("failed to get shellpane config schema: %v", err.Error())
+		}
+
+		_, _ = os.Stdout.Write(schema)
+
+		return
+	}
+
+	// prints a new api token and the hash to configure it with
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		token, err := business.NewAPIToken()
+		if err != nil {
+			log.Fatalf("failed to create api token: %v", err.Error())
+		}
+
+		fmt.Printf("token: %v\nhash: %v\n", token, domain.HashAPIToken(token))
+
+		return
+	}
+
 	config, err := getConfig(os.Args[1:])
 	if err != nil {
 		log.Fatalf("failed to get config: %v", err.Error())
@@ -88,6 +172,34 @@ func main() {
 			return errors.Wrapf(err, "failed to get server")
 		}
 
+		hupCh := make(chan os.Signal, 1)
+		signal.Notify(hupCh, syscall.SIGHUP)
+
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hupCh:
+				}
+
+				err := c.ReloadConfigs(ctx)
+				if err != nil {
+					logger.With("error", errors.Wrapf(err, "failed to reload configs, keep serving previous configs")).Error()
+					continue
+				}
+
+				logger.Info("reloaded configs on SIGHUP")
+			}
+		}()
+
+		go func() {
+			err := c.WatchConfigs(ctx)
+			if err != nil {
+				logger.With("error", errors.Wrapf(err, "failed to watch configs")).Error()
+			}
+		}()
+
 		l, err := c.GetHTTPListener(ctx)
 		if err != nil {
 			return errors.Wrapf(err, "failed to get http listener")
@@ -0,0 +1,453 @@
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+// configEntityKind is a kind of entity of the config that the admin api changes
+type configEntityKind struct {
+	// source is the kind the loader records the files of the entities as
+	source string
+	// idKey is the yaml key of the id of the entities
+	idKey string
+	t     reflect.Type
+}
+
+// configEntityKinds are the kinds of entities the admin api changes by the yaml key of their list
+var configEntityKinds = map[string]configEntityKind{
+	"users":      {source: "user", idKey: "id", t: reflect.TypeOf(UserConfig{})},
+	"groups":     {source: "group", idKey: "slug", t: reflect.TypeOf(GroupConfig{})},
+	"roles":      {source: "role", idKey: "slug", t: reflect.TypeOf(RoleConfig{})},
+	"categories": {source: "category", idKey: "slug", t: reflect.TypeOf(CategoryConfig{})},
+	"views":      {source: "view", idKey: "slug", t: reflect.TypeOf(ViewConfig{})},
+	"commands":   {source: "command", idKey: "slug", t: reflect.TypeOf(CommandConfig{})},
+	"inputs":     {source: "input", idKey: "slug", t: reflect.TypeOf(InputConfig{})},
+	"tokens":     {source: "token", idKey: "slug", t: reflect.TypeOf(APITokenConfig{})},
+}
+
+func getConfigEntityKind(kind string) (configEntityKind, error) {
+	k, ok := configEntityKinds[kind]
+	if !ok {
+		return configEntityKind{}, errutil.Invalid(errors.Errorf("unknown kind=%v", kind))
+	}
+
+	return k, nil
+}
+
+// configFiles are the yaml documents of the files of the config as they are written, without interpolation
+type configFiles struct {
+	loader   *configLoader
+	docs     map[string][]*yaml.Node
+	revision string
+}
+
+// readConfigFiles reads the files of the config, the revision of the config changes whenever a file changes
+func readConfigFiles(fs afero.Fs, path string) (configFiles, error) {
+	l := newConfigLoader(fs)
+	err := l.read(path)
+	if err != nil {
+		return configFiles{}, errors.Wrapf(err, "failed to read shellpane config path=%v", path)
+	}
+
+	files := configFiles{
+		loader: l,
+		docs:   map[string][]*yaml.Node{},
+	}
+
+	h := sha256.New()
+	for _, file := range l.files {
+		b, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return configFiles{}, errors.Wrapf(err, "failed to read file=%v", file)
+		}
+
+		h.Write([]byte(file))
+		h.Write([]byte{0})
+		h.Write(b)
+		h.Write([]byte{0})
+
+		d := yaml.NewDecoder(bytes.NewReader(b))
+		for {
+			var doc yaml.Node
+			err := d.Decode(&doc)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return configFiles{}, errors.Wrapf(err, "failed to yaml unmarshal file=%v", file)
+			}
+
+			files.docs[file] = append(files.docs[file], &doc)
+		}
+	}
+	files.revision = hex.EncodeToString(h.Sum(nil))
+
+	return files, nil
+}
+
+// findEntity returns the list of the entities of a kind in a file and the index of the entity with id
+func (f configFiles) findEntity(kind string, k configEntityKind, id string) (string, *yaml.Node, int, bool) {
+	sources := f.loader.sources[k.source][id]
+	if len(sources) == 0 {
+		return "", nil, 0, false
+	}
+
+	for _, doc := range f.docs[sources[0]] {
+		items := mappingValue(doc, kind)
+		if items == nil || resolveAlias(items).Kind != yaml.SequenceNode {
+			continue
+		}
+		items = resolveAlias(items)
+
+		for i := range items.Content {
+			v := mappingValue(items.Content[i], k.idKey)
+			if v != nil && resolveAlias(v).Value == id {
+				return sources[0], items, i, true
+			}
+		}
+	}
+
+	return "", nil, 0, false
+}
+
+// encode returns the yaml documents of file
+func (f configFiles) encode(file string) ([]byte, error) {
+	var b bytes.Buffer
+	e := yaml.NewEncoder(&b)
+	e.SetIndent(2)
+
+	for _, doc := range f.docs[file] {
+		err := e.Encode(doc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to yaml marshal file=%v", file)
+		}
+	}
+
+	err := e.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to close yaml encoder")
+	}
+
+	return b.Bytes(), nil
+}
+
+// decodeConfigEntity decodes a json entity into a yaml node in block style,
+// it fails if the entity has unknown keys, doesn't decode into its kind or has no id
+func decodeConfigEntity(k configEntityKind, entity json.RawMessage) (*yaml.Node, string, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(entity, &doc)
+	if err != nil {
+		return nil, "", errutil.Invalid(errors.Wrapf(err, "failed to json unmarshal entity"))
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, "", errutil.Invalid(errors.New("entity is not an object"))
+	}
+	node := doc.Content[0]
+
+	errs := unknownKeys(node, k.t, "")
+	if len(errs) != 0 {
+		return nil, "", errutil.Invalid(joinLocatedErrors(errs))
+	}
+
+	err = node.Decode(reflect.New(k.t).Interface())
+	if err != nil {
+		return nil, "", errutil.Invalid(errors.Wrapf(err, "failed to decode entity"))
+	}
+
+	id := mappingValue(node, k.idKey)
+	if id == nil || id.Value == "" {
+		return nil, "", errutil.Invalid(errors.Errorf("%v is empty", k.idKey))
+	}
+
+	setBlockStyle(node)
+
+	return node, id.Value, nil
+}
+
+// setBlockStyle resets the json styles of a node, so that it is written like the rest of the config,
+// strings that would be read as another type are still quoted
+func setBlockStyle(node *yaml.Node) {
+	node.Style = 0
+	for i := range node.Content {
+		setBlockStyle(node.Content[i])
+	}
+}
+
+// GetConfigEntities returns the entities of a kind as they are written in the config files and the revision of the config
+func (c *Container) GetConfigEntities(ctx context.Context, kind string) ([]json.RawMessage, string, error) {
+	_, err := getConfigEntityKind(kind)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	files, err := c.readConfigFiles(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entities := []json.RawMessage{}
+	for _, file := range files.loader.files {
+		for _, doc := range files.docs[file] {
+			items := mappingValue(doc, kind)
+			if items == nil || resolveAlias(items).Kind != yaml.SequenceNode {
+				continue
+			}
+			items = resolveAlias(items)
+
+			for i := range items.Content {
+				var v interface{}
+				err := items.Content[i].Decode(&v)
+				if err != nil {
+					return nil, "", errors.Wrapf(err, "failed to decode %v[%v] of file=%v", kind, i, file)
+				}
+
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, "", errors.Wrapf(err, "failed to json marshal %v[%v] of file=%v", kind, i, file)
+				}
+
+				entities = append(entities, b)
+			}
+		}
+	}
+
+	return entities, files.revision, nil
+}
+
+// CreateConfigEntity appends an entity to the entities of its kind in the first config file
+func (c *Container) CreateConfigEntity(ctx context.Context, kind string, entity json.RawMessage, revision string) (string, error) {
+	k, err := getConfigEntityKind(kind)
+	if err != nil {
+		return "", err
+	}
+
+	node, id, err := decodeConfigEntity(k, entity)
+	if err != nil {
+		return "", err
+	}
+
+	return c.changeConfig(ctx, revision, func(files configFiles) (string, error) {
+		_, exists := files.loader.sources[k.source][id]
+		if exists {
+			return "", errutil.AlreadyExists(errors.Errorf("%v=%v is already defined", k.source, id), k.source, id)
+		}
+
+		file := files.loader.files[0]
+		if len(files.docs[file]) == 0 {
+			files.docs[file] = []*yaml.Node{{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}}
+		}
+
+		doc := files.docs[file][0]
+		items := mappingValue(doc, kind)
+		if items == nil {
+			root := resolveAlias(doc.Content[0])
+			items = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: kind}, items)
+		}
+
+		items = resolveAlias(items)
+		items.Style = 0
+		items.Content = append(items.Content, node)
+
+		return file, nil
+	})
+}
+
+// UpdateConfigEntity replaces the entity with id in the file that defines it
+func (c *Container) UpdateConfigEntity(ctx context.Context, kind string, id string, entity json.RawMessage, revision string) (string, error) {
+	k, err := getConfigEntityKind(kind)
+	if err != nil {
+		return "", err
+	}
+
+	node, newID, err := decodeConfigEntity(k, entity)
+	if err != nil {
+		return "", err
+	}
+
+	return c.changeConfig(ctx, revision, func(files configFiles) (string, error) {
+		file, items, i, ok := files.findEntity(kind, k, id)
+		if !ok {
+			return "", errutil.NotFound(errors.Errorf("%v=%v is not defined in a config file", k.source, id), k.source, id)
+		}
+
+		_, exists := files.loader.sources[k.source][newID]
+		if newID != id && exists {
+			return "", errutil.AlreadyExists(errors.Errorf("%v=%v is already defined", k.source, newID), k.source, newID)
+		}
+
+		node.HeadComment = items.Content[i].HeadComment
+		items.Content[i] = node
+
+		return file, nil
+	})
+}
+
+// DeleteConfigEntity removes the entity with id from the file that defines it
+func (c *Container) DeleteConfigEntity(ctx context.Context, kind string, id string, revision string) (string, error) {
+	k, err := getConfigEntityKind(kind)
+	if err != nil {
+		return "", err
+	}
+
+	return c.changeConfig(ctx, revision, func(files configFiles) (string, error) {
+		file, items, i, ok := files.findEntity(kind, k, id)
+		if !ok {
+			return "", errutil.NotFound(errors.Errorf("%v=%v is not defined in a config file", k.source, id), k.source, id)
+		}
+
+		items.Content = append(items.Content[:i], items.Content[i+1:]...)
+
+		return file, nil
+	})
+}
+
+func (c *Container) readConfigFiles(ctx context.Context) (configFiles, error) {
+	if c.opts.Config.ShellpaneYAMLPath == "" {
+		return configFiles{}, errutil.Invalid(errors.New("the config can only be changed if it is read from a yaml file, stored configs that are served without one are read only"))
+	}
+
+	fs, err := c.GetFS(ctx)
+	if err != nil {
+		return configFiles{}, errors.Wrapf(err, "failed to get filesystem")
+	}
+
+	return readConfigFiles(fs, c.opts.Config.ShellpaneYAMLPath)
+}
+
+// changeConfig applies change to the documents of the config files if revision is the current revision,
+// validates the changed config, writes the changed file back and swaps the changed config in.
+// change returns the file it changed.
+func (c *Container) changeConfig(ctx context.Context, revision string, change func(files configFiles) (string, error)) (string, error) {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	fs, err := c.GetFS(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get filesystem")
+	}
+
+	files, err := c.readConfigFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if revision != files.revision {
+		return "", errutil.Conflict(errors.Errorf("revision=%v is not the current revision=%v", revision, files.revision), "config revision", revision)
+	}
+
+	file, err := change(files)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := files.encode(file)
+	if err != nil {
+		return "", err
+	}
+
+	// the changed config is validated like a reload would, without writing the file yet
+	overlay := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(fs), afero.NewMemMapFs())
+	err = afero.WriteFile(overlay, file, b, 0644)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write file=%v to overlay", file)
+	}
+
+	l := newConfigLoader(overlay)
+	err = l.read(c.opts.Config.ShellpaneYAMLPath)
+	if err != nil {
+		return "", errutil.Invalid(errors.Wrapf(err, "failed to read changed config"))
+	}
+
+	err = l.validate()
+	if err != nil {
+		return "", errutil.Invalid(errors.Wrapf(err, "failed to validate changed config"))
+	}
+
+	info, err := fs.Stat(file)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to stat file=%v", file)
+	}
+
+	previous, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read file=%v", file)
+	}
+
+	err = writeFileAtomically(fs, file, b, info.Mode()&os.ModePerm)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write file=%v", file)
+	}
+
+	err = c.loadConfigs(ctx)
+	if err == nil {
+		err = c.swapConfigs()
+	}
+	if err != nil {
+		// the previous file is restored, so that the file and the served configs don't diverge
+		restoreErr := writeFileAtomically(fs, file, previous, info.Mode()&os.ModePerm)
+		if restoreErr == nil {
+			restoreErr = c.loadConfigs(ctx)
+		}
+		if restoreErr != nil {
+			return "", errors.Wrapf(err, "failed to apply changed configs and the configs diverged from file=%v, restoring the previous file failed: %v", file, restoreErr)
+		}
+
+		return "", errors.Wrapf(err, "failed to apply changed configs, the previous file=%v was restored", file)
+	}
+
+	changed, err := readConfigFiles(fs, c.opts.Config.ShellpaneYAMLPath)
+	if err != nil {
+		return "", err
+	}
+
+	return changed.revision, nil
+}
+
+// writeFileAtomically writes b to a temporary file next to file and renames it over file,
+// so that a crash or a concurrent reader never sees a partially written file
+func writeFileAtomically(fs afero.Fs, file string, b []byte, perm os.FileMode) error {
+	f, err := afero.TempFile(fs, filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file")
+	}
+
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.Chmod(f.Name(), perm)
+	}
+	if err == nil {
+		err = fs.Rename(f.Name(), file)
+	}
+	if err != nil {
+		_ = fs.Remove(f.Name())
+
+		return errors.Wrapf(err, "failed to write temporary file=%v", f.Name())
+	}
+
+	return nil
+}
@@ -2,24 +2,27 @@ package bootstrap
 
 import (
 	"context"
+	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/test/bufconn"
-	"gopkg.in/yaml.v3"
 
 	"github.com/ppwfx/shellpane/internal/business"
 	"github.com/ppwfx/shellpane/internal/communication"
 	"github.com/ppwfx/shellpane/internal/domain"
 	"github.com/ppwfx/shellpane/internal/persistence"
 	"github.com/ppwfx/shellpane/internal/utils/httputils"
+	"github.com/ppwfx/shellpane/internal/utils/jwtutil"
 	"github.com/ppwfx/shellpane/internal/utils/logutil"
 )
 
@@ -35,8 +38,13 @@ type ContainerConfig struct {
 	Business          business.Config
 	Communication     communication.Config
 	ShellpaneYAMLPath string
-	FS                string
-	ShellpaneConfig   *ShellpaneConfig
+	// ShellpaneYAMLWatchInterval is how often ShellpaneYAMLPath is checked for changes, it is not watched if zero
+	ShellpaneYAMLWatchInterval time.Duration
+	FS                         string
+	ShellpaneConfig            *ShellpaneConfig
+	// StorePath is the bbolt database the configs and runs are persisted in. The shellpane config is imported into it
+	// on start and on reload, without a shellpane config the stored configs are served read only.
+	StorePath string
 }
 
 type ContainerOpts struct {
@@ -50,6 +58,7 @@ type namedCloser struct {
 
 type Container struct {
 	opts              ContainerOpts
+	reloadMu          *sync.Mutex
 	closers           []namedCloser
 	handler           *business.Handler
 	router            http.Handler
@@ -59,20 +68,30 @@ type Container struct {
 	roundTripper      http.RoundTripper
 	logger            *zap.SugaredLogger
 	client            *communication.Client
-	repository        *persistence.Repository
+	repository        persistence.Repository
+	runRepository     persistence.RunRepository
+	diskRepository    *persistence.DiskRepository
+	jwtVerifier       *jwtutil.Verifier
+	inputOptionsCache *persistence.InputOptionsCache
 	userConfigs       map[string]domain.UserConfig
+	groupConfigs      map[string]domain.GroupConfig
 	viewConfigs       []domain.ViewConfig
 	categoryConfigs   []domain.CategoryConfig
 	commandsConfig    map[string]domain.CommandConfig
+	sequenceConfigs   map[string]domain.SequenceConfig
 	allowedCategories map[string]map[string]struct{}
 	allowedViews      map[string]map[string]struct{}
 	allowedCommands   map[string]map[string]struct{}
+	secretValues      map[string]string
+	apiTokens         map[string]domain.APIToken
+	configFiles       []string
 	fs                afero.Fs
 }
 
 func NewContainer(opts ContainerOpts) Container {
 	return Container{
-		opts: opts,
+		opts:     opts,
+		reloadMu: &sync.Mutex{},
 	}
 }
 
@@ -88,7 +107,7 @@ func (c *Container) Close(ctx context.Context) (errs []error) {
 	return
 }
 
-func (c Container) GetHandler(ctx context.Context) (business.Handler, error) {
+func (c *Container) GetHandler(ctx context.Context) (business.Handler, error) {
 	if c.handler != nil {
 		return *c.handler, nil
 	}
@@ -98,9 +117,22 @@ func (c Container) GetHandler(ctx context.Context) (business.Handler, error) {
 		return business.Handler{}, errors.Wrapf(err, "failed to get repository")
 	}
 
+	runRepository, err := c.GetRunRepository(ctx)
+	if err != nil {
+		return business.Handler{}, errors.Wrapf(err, "failed to get run repository")
+	}
+
+	inputOptionsCache, err := c.GetInputOptionsCache(ctx)
+	if err != nil {
+		return business.Handler{}, errors.Wrapf(err, "failed to get input options cache")
+	}
+
 	h := business.NewHandler(business.HandlerOpts{
-		Config:     c.opts.Config.Business.Handler,
-		Repository: repository,
+		Config:            c.opts.Config.Business.Handler,
+		Repository:        repository,
+		RunRepository:     runRepository,
+		InputOptionsCache: inputOptionsCache,
+		ConfigAdmin:       c,
 	})
 
 	c.handler = &h
@@ -108,7 +140,7 @@ func (c Container) GetHandler(ctx context.Context) (business.Handler, error) {
 	return *c.handler, nil
 }
 
-func (c Container) GetRouter(ctx context.Context) (http.Handler, error) {
+func (c *Container) GetRouter(ctx context.Context) (http.Handler, error) {
 	if c.router != nil {
 		return c.router, nil
 	}
@@ -118,15 +150,15 @@ func (c Container) GetRouter(ctx context.Context) (http.Handler, error) {
 		return nil, errors.Wrap(err, "failed to get handler")
 	}
 
-	_, _, categoriesConfig, _, _, _, _, err := c.GetConfigs(ctx)
+	schema, err := ShellpaneConfigSchema()
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get config")
+		return nil, errors.Wrapf(err, "failed to get shellpane config schema")
 	}
 
 	router := communication.NewRouter(communication.RouterOpts{
-		CategoryConfigs: categoriesConfig,
-		Config:          c.opts.Config.Communication.Router,
-		Handler:         h,
+		Config:  c.opts.Config.Communication.Router,
+		Handler: h,
+		Schema:  schema,
 	})
 
 	logger, err := c.GetLogger(ctx)
@@ -134,20 +166,53 @@ func (c Container) GetRouter(ctx context.Context) (http.Handler, error) {
 		return nil, errors.Wrapf(err, "failed to get logger")
 	}
 
+	// requests with an api token skip the authentication of users
+	authenticated := router
+
 	if c.opts.Config.Communication.Router.BasicAuth.Username != "" &&
 		c.opts.Config.Communication.Router.BasicAuth.Password != "" {
-		router = communication.WithBasicAuthMiddleware(router, c.opts.Config.Communication.Router.BasicAuth)
+		authenticated = communication.WithBasicAuthMiddleware(authenticated, c.opts.Config.Communication.Router.BasicAuth)
+	}
+
+	if c.opts.Config.Communication.JWT.JWKS != "" || c.opts.Config.Communication.JWT.KeyFile != "" {
+		if c.opts.Config.Communication.UserIDHeader != "" || c.opts.Config.Communication.GroupsHeader != "" {
+			return nil, errors.Errorf("jwt authentication can't be combined with a user id header or a groups header")
+		}
+
+		// both read the authorization header, so no request could satisfy both
+		if c.opts.Config.Communication.Router.BasicAuth.Username != "" ||
+			c.opts.Config.Communication.Router.BasicAuth.Password != "" {
+			return nil, errors.Errorf("jwt authentication can't be combined with basic auth")
+		}
+
+		verifier, err := c.GetJWTVerifier(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get jwt verifier")
+		}
+
+		authenticated = communication.WithJWTMiddleware(authenticated, c.opts.Config.Communication.JWT, verifier)
+	}
+
+	if c.opts.Config.Communication.GroupsHeader != "" {
+		if c.opts.Config.Communication.UserIDHeader == "" {
+			return nil, errors.Errorf("groups header requires a user id header")
+		}
+
+		authenticated = communication.WithGroupsMiddleware(authenticated, c.opts.Config.Communication.GroupsHeader)
 	}
 
 	if c.opts.Config.Communication.UserIDHeader != "" {
-		router = communication.WithUserIDMiddleware(router, c.opts.Config.Communication.UserIDHeader, c.opts.Config.Communication.DefaultUserID)
+		authenticated = communication.WithUserIDMiddleware(authenticated, c.opts.Config.Communication.UserIDHeader, c.opts.Config.Communication.DefaultUserID)
 	}
 
+	router = communication.WithAPITokenMiddleware(router, authenticated, h)
+
 	if c.opts.Config.Communication.CorsOrigin != "" {
 		router = communication.CorsMiddleware(router, c.opts.Config.Communication.CorsOrigin)
 	}
 
-	router = logutil.LogRequestMiddleware(router)
+	// input values may be secrets, so they are not logged
+	router = logutil.LogRequestMiddleware(router, "input_")
 	router = logutil.WithLoggerValueMiddleware(logger)(router)
 
 	c.router = router
@@ -155,6 +220,61 @@ func (c Container) GetRouter(ctx context.Context) (http.Handler, error) {
 	return c.router, nil
 }
 
+// GetJWTVerifier returns a verifier of bearer tokens that are signed with a key of the jwks or with the key file
+func (c *Container) GetJWTVerifier(ctx context.Context) (jwtutil.Verifier, error) {
+	if c.jwtVerifier != nil {
+		return *c.jwtVerifier, nil
+	}
+
+	config := c.opts.Config.Communication.JWT
+
+	fs, err := c.GetFS(ctx)
+	if err != nil {
+		return jwtutil.Verifier{}, errors.Wrapf(err, "failed to get fs")
+	}
+
+	var keys jwtutil.Keys
+	switch {
+	case strings.HasPrefix(config.JWKS, "https://") || strings.HasPrefix(config.JWKS, "http://"):
+		keys = jwtutil.NewRemoteJWKS(config.JWKS, &http.Client{Timeout: 10 * time.Second})
+	case config.JWKS != "":
+		b, err := afero.ReadFile(fs, config.JWKS)
+		if err != nil {
+			return jwtutil.Verifier{}, errors.Wrapf(err, "failed to read jwks file=%v", config.JWKS)
+		}
+
+		keys, err = jwtutil.ParseJWKS(b)
+		if err != nil {
+			return jwtutil.Verifier{}, errors.Wrapf(err, "failed to parse jwks file=%v", config.JWKS)
+		}
+	case config.KeyFile != "":
+		b, err := afero.ReadFile(fs, config.KeyFile)
+		if err != nil {
+			return jwtutil.Verifier{}, errors.Wrapf(err, "failed to read key file=%v", config.KeyFile)
+		}
+
+		keys, err = jwtutil.ParseStaticKey(b)
+		if err != nil {
+			return jwtutil.Verifier{}, errors.Wrapf(err, "failed to parse key file=%v", config.KeyFile)
+		}
+	default:
+		return jwtutil.Verifier{}, errors.Errorf("neither jwks nor key file is set")
+	}
+
+	verifier := jwtutil.NewVerifier(jwtutil.VerifierOpts{
+		Config: jwtutil.VerifierConfig{
+			Issuer:   config.Issuer,
+			Audience: config.Audience,
+			Leeway:   time.Minute,
+		},
+		Keys: keys,
+	})
+
+	c.jwtVerifier = &verifier
+
+	return *c.jwtVerifier, nil
+}
+
 func (c *Container) GetHTTPServer(ctx context.Context) (*http.Server, error) {
 	if c.httpServer != nil {
 		return c.httpServer, nil
@@ -293,27 +413,141 @@ func (c *Container) GetClient(ctx context.Context) (communication.Client, error)
 
 func (c *Container) GetRepository(ctx context.Context) (persistence.Repository, error) {
 	if c.repository != nil {
-		return *c.repository, nil
+		return c.repository, nil
+	}
+
+	if c.opts.Config.StorePath == "" {
+		_, _, _, _, _, _, _, _, _, err := c.GetConfigs(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get configs")
+		}
+
+		c.repository = persistence.NewMemoryRepository(c.getRepositoryOpts())
+
+		return c.repository, nil
 	}
 
-	userConfigs, viewConfigs, categoryConfigs, commandsConfigs, allowedCategories, allowedViews, allowedCommands, err := c.GetConfigs(ctx)
+	diskRepository, err := c.GetDiskRepository(ctx)
 	if err != nil {
-		return persistence.Repository{}, errors.Wrapf(err, "failed to get configs")
+		return nil, errors.Wrapf(err, "failed to get disk repository")
 	}
 
-	repository := persistence.NewRepository(persistence.RepositoryOpts{
-		UserConfigs:           userConfigs,
-		ViewConfigs:           viewConfigs,
-		CommandConfigs:        commandsConfigs,
-		CategoryConfigs:       categoryConfigs,
-		UserAllowedCategories: allowedCategories,
-		UserAllowedViews:      allowedViews,
-		UserAllowedCommands:   allowedCommands,
-	})
+	// without a shellpane config the configs that were imported before are served
+	if c.opts.Config.ShellpaneConfig == nil && c.opts.Config.ShellpaneYAMLPath == "" {
+		if !diskRepository.HasConfig() {
+			return nil, errors.Errorf("no config present, neither as shellpane config nor in store path=%v", c.opts.Config.StorePath)
+		}
+
+		// secret values are not stored, so commands that need them can only run if the config is imported again
+		secretSlugs := storedSecretSlugs(diskRepository.GetCommandConfigs())
+		if len(secretSlugs) != 0 {
+			return nil, errors.Errorf("stored commands use secrets=%v whose values are not stored in path=%v, serve the store with the shellpane config that defines the secrets", strings.Join(secretSlugs, ","), c.opts.Config.StorePath)
+		}
+
+		c.repository = diskRepository
+
+		return c.repository, nil
+	}
+
+	_, _, _, _, _, _, _, _, _, err = c.GetConfigs(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get configs")
+	}
+
+	err = diskRepository.Swap(c.getRepositoryOpts())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to import configs into store")
+	}
+
+	c.repository = diskRepository
+
+	return c.repository, nil
+}
+
+// storedSecretSlugs returns the sorted slugs of the secrets commands use
+func storedSecretSlugs(commands map[string]domain.CommandConfig) []string {
+	seen := map[string]struct{}{}
+	var slugs []string
+	for _, command := range commands {
+		for _, secret := range command.Secrets {
+			_, ok := seen[secret.SecretSlug]
+			if ok {
+				continue
+			}
+			seen[secret.SecretSlug] = struct{}{}
+
+			slugs = append(slugs, secret.SecretSlug)
+		}
+	}
+	sort.Strings(slugs)
+
+	return slugs
+}
+
+// GetDiskRepository opens the repository at StorePath, it serves as repository and as run repository
+func (c *Container) GetDiskRepository(ctx context.Context) (persistence.DiskRepository, error) {
+	if c.diskRepository != nil {
+		return *c.diskRepository, nil
+	}
+
+	diskRepository, err := persistence.OpenDiskRepository(c.opts.Config.StorePath)
+	if err != nil {
+		return persistence.DiskRepository{}, errors.Wrapf(err, "failed to open disk repository path=%v", c.opts.Config.StorePath)
+	}
+
+	c.diskRepository = &diskRepository
+	c.closers = append(c.closers, namedCloser{name: "disk repository", closer: diskRepository})
 
-	c.repository = &repository
+	return *c.diskRepository, nil
+}
 
-	return *c.repository, nil
+func (c *Container) getRepositoryOpts() persistence.RepositoryOpts {
+	return persistence.RepositoryOpts{
+		UserConfigs:           c.userConfigs,
+		GroupConfigs:          c.groupConfigs,
+		ViewConfigs:           c.viewConfigs,
+		CommandConfigs:        c.commandsConfig,
+		SequenceConfigs:       c.sequenceConfigs,
+		CategoryConfigs:       c.categoryConfigs,
+		UserAllowedCategories: c.allowedCategories,
+		UserAllowedViews:      c.allowedViews,
+		UserAllowedCommands:   c.allowedCommands,
+		SecretValues:          c.secretValues,
+		APITokens:             c.apiTokens,
+	}
+}
+
+func (c *Container) GetRunRepository(ctx context.Context) (persistence.RunRepository, error) {
+	if c.runRepository != nil {
+		return c.runRepository, nil
+	}
+
+	if c.opts.Config.StorePath == "" {
+		c.runRepository = persistence.NewMemoryRunRepository()
+
+		return c.runRepository, nil
+	}
+
+	diskRepository, err := c.GetDiskRepository(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get disk repository")
+	}
+
+	c.runRepository = diskRepository
+
+	return c.runRepository, nil
+}
+
+func (c *Container) GetInputOptionsCache(ctx context.Context) (persistence.InputOptionsCache, error) {
+	if c.inputOptionsCache != nil {
+		return *c.inputOptionsCache, nil
+	}
+
+	inputOptionsCache := persistence.NewInputOptionsCache()
+
+	c.inputOptionsCache = &inputOptionsCache
+
+	return *c.inputOptionsCache, nil
 }
 
 func (c *Container) GetConfigs(ctx context.Context) (
@@ -321,51 +555,178 @@ func (c *Container) GetConfigs(ctx context.Context) (
 	[]domain.ViewConfig,
 	[]domain.CategoryConfig,
 	map[string]domain.CommandConfig,
+	map[string]domain.SequenceConfig,
 	map[string]map[string]struct{},
 	map[string]map[string]struct{},
 	map[string]map[string]struct{},
+	map[string]string,
 	error,
 ) {
 	if c.viewConfigs != nil {
-		return c.userConfigs, c.viewConfigs, c.categoryConfigs, c.commandsConfig, c.allowedCategories, c.allowedViews, c.allowedCommands, nil
+		return c.userConfigs, c.viewConfigs, c.categoryConfigs, c.commandsConfig, c.sequenceConfigs, c.allowedCategories, c.allowedViews, c.allowedCommands, c.secretValues, nil
 	}
 
+	err := c.loadConfigs(ctx)
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to load configs")
+	}
+
+	return c.userConfigs, c.viewConfigs, c.categoryConfigs, c.commandsConfig, c.sequenceConfigs, c.allowedCategories, c.allowedViews, c.allowedCommands, c.secretValues, nil
+}
+
+// loadConfigs reads, validates and generates the configs and only replaces the current configs if all of it succeeds
+func (c *Container) loadConfigs(ctx context.Context) error {
 	fs, err := c.GetFS(ctx)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to get filesystem")
+		return errors.Wrapf(err, "failed to get filesystem")
 	}
 
-	var config ShellpaneConfig
+	l := newConfigLoader(fs)
 	switch {
 	case c.opts.Config.ShellpaneConfig != nil:
-		config = *c.opts.Config.ShellpaneConfig
-	case c.opts.Config.ShellpaneYAMLPath != "":
-		f, err := fs.Open(c.opts.Config.ShellpaneYAMLPath)
+		err = l.merge("inline config", ".", *c.opts.Config.ShellpaneConfig, nil)
 		if err != nil {
-			return nil, nil, nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to open file=%v", c.opts.Config.ShellpaneYAMLPath)
+			return errors.Wrapf(err, "failed to merge inline config")
 		}
-
-		b, err := ioutil.ReadAll(f)
+	case c.opts.Config.ShellpaneYAMLPath != "":
+		err = l.read(c.opts.Config.ShellpaneYAMLPath)
+		c.configFiles = l.files
 		if err != nil {
-			return nil, nil, nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to read file=%v", c.opts.Config.ShellpaneYAMLPath)
+			return errors.Wrapf(err, "failed to read shellpane config path=%v", c.opts.Config.ShellpaneYAMLPath)
 		}
+	default:
+		return errors.New("no config present")
+	}
+
+	err = l.validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate shellpane config")
+	}
+
+	config := l.config
+
+	secretValues, err := loadSecrets(fs, config.Secrets)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load secrets")
+	}
 
-		err = yaml.Unmarshal(b, &config)
+	c.secretValues = secretValues
+	c.userConfigs, c.groupConfigs, c.viewConfigs, c.categoryConfigs, c.commandsConfig, c.sequenceConfigs, c.allowedCategories, c.allowedViews, c.allowedCommands = generateConfigs(config)
+	c.apiTokens = generateAPITokens(config.Tokens)
+
+	return nil
+}
+
+// ReloadConfigs loads the configs again and swaps them into the repository, so that
+// requests that are served already finish with the previous configs. If the configs are invalid, the previous configs are kept.
+func (c *Container) ReloadConfigs(ctx context.Context) error {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	err := c.loadConfigs(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load configs")
+	}
+
+	err = c.swapConfigs()
+	if err != nil {
+		return errors.Wrapf(err, "failed to swap configs")
+	}
+
+	return nil
+}
+
+// swapConfigs swaps the loaded configs into the repository and clears the cached input options,
+// as the commands they come from may have changed
+func (c *Container) swapConfigs() error {
+	if c.repository != nil {
+		err := c.repository.Swap(c.getRepositoryOpts())
 		if err != nil {
-			return nil, nil, nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to yaml unmarshal file=%v content=%v", c.opts.Config.ShellpaneYAMLPath, string(b))
+			return err
 		}
-	default:
-		return nil, nil, nil, nil, nil, nil, nil, errors.New("no config present")
 	}
 
-	err = ValidateShellpaneConfig(config)
+	if c.inputOptionsCache != nil {
+		c.inputOptionsCache.Clear()
+	}
+
+	return nil
+}
+
+// WatchConfigs reloads the configs whenever the modification time or size of a config file changes
+// or config files are added to or removed from ShellpaneYAMLPath, it returns when ctx is done
+func (c *Container) WatchConfigs(ctx context.Context) error {
+	if c.opts.Config.ShellpaneYAMLPath == "" || c.opts.Config.ShellpaneYAMLWatchInterval <= 0 {
+		return nil
+	}
+
+	logger, err := c.GetLogger(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get logger")
+	}
+
+	fs, err := c.GetFS(ctx)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to validate shellpane config")
+		return errors.Wrapf(err, "failed to get filesystem")
 	}
 
-	c.userConfigs, c.viewConfigs, c.categoryConfigs, c.commandsConfig, c.allowedCategories, c.allowedViews, c.allowedCommands = generateConfigs(config)
+	lastFingerprint := c.getConfigFingerprint(fs)
+
+	ticker := time.NewTicker(c.opts.Config.ShellpaneYAMLWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		fingerprint := c.getConfigFingerprint(fs)
+		if fingerprint == lastFingerprint {
+			continue
+		}
+
+		err = c.ReloadConfigs(ctx)
+		lastFingerprint = c.getConfigFingerprint(fs)
+		if err != nil {
+			logger.With("error", errors.Wrapf(err, "failed to reload configs, keep serving previous configs")).Error()
+
+			continue
+		}
+
+		logger.Infof("reloaded configs from path=%v", c.opts.Config.ShellpaneYAMLPath)
+	}
+}
+
+// getConfigFingerprint describes the paths, modification times and sizes of the config files,
+// it includes files that were loaded last time and files that ShellpaneYAMLPath resolves to now
+func (c *Container) getConfigFingerprint(fs afero.Fs) string {
+	c.reloadMu.Lock()
+	files := append([]string{}, c.configFiles...)
+	c.reloadMu.Unlock()
+
+	paths, _ := resolveConfigPaths(fs, c.opts.Config.ShellpaneYAMLPath)
+	files = append(files, paths...)
+	sort.Strings(files)
+
+	var b strings.Builder
+	for i, f := range files {
+		if i > 0 && files[i-1] == f {
+			continue
+		}
+
+		info, err := fs.Stat(f)
+		if err != nil {
+			fmt.Fprintf(&b, "%v:missing;", f)
+
+			continue
+		}
+
+		fmt.Fprintf(&b, "%v:%v:%v;", f, info.ModTime().UnixNano(), info.Size())
+	}
 
-	return c.userConfigs, c.viewConfigs, c.categoryConfigs, c.commandsConfig, c.allowedCategories, c.allowedViews, c.allowedCommands, nil
+	return b.String()
 }
 
 func (c *Container) GetFS(ctx context.Context) (afero.Fs, error) {
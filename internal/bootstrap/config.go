@@ -1,8 +1,21 @@
 package bootstrap
 
-import "github.com/ppwfx/shellpane/internal/domain"
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+)
 
 type ShellpaneConfig struct {
+	// Include lists yaml files, directories or globs whose configs are merged into this config,
+	// relative paths are relative to the directory of the including file
+	Include    []string
 	Users      []UserConfig
 	Groups     []GroupConfig
 	Roles      []RoleConfig
@@ -11,6 +24,29 @@ type ShellpaneConfig struct {
 	Sequences  []SequenceConfig
 	Commands   []CommandConfig
 	Inputs     []InputConfig
+	Secrets    []SecretConfig
+	// Redact rules apply to the output of all commands
+	Redact []RedactConfig
+	Tokens []APITokenConfig
+}
+
+// APITokenConfig is a token machine clients authenticate with as bearer token, only its hash is configured
+type APITokenConfig struct {
+	Slug string
+	// Owner is the id of the user whose permissions the token is limited to
+	Owner string
+	// Hash is the hex encoded sha256 hash of the token
+	Hash      string
+	ExpiresAt time.Time `yaml:"expiresAt"`
+	Commands  []string
+	Views     []string
+}
+
+// SecretConfig loads the value of a secret from either an env var of the server or a file
+type SecretConfig struct {
+	Slug     string
+	FromEnv  string `yaml:"fromEnv"`
+	FromFile string `yaml:"fromFile"`
 }
 
 type UserConfig struct {
@@ -23,8 +59,9 @@ type UserGroupConfig struct {
 }
 
 type GroupConfig struct {
-	Slug  string
-	Roles []GroupRoleConfig
+	Slug         string
+	Roles        []GroupRoleConfig
+	HeaderGroups []string `yaml:"headerGroups"`
 }
 
 type GroupRoleConfig struct {
@@ -35,10 +72,22 @@ type RoleConfig struct {
 	Slug       string
 	Views      []RoleViewConfig
 	Categories []RoleCategoryConfig
+	// Admin grants changing the config through the admin api
+	Admin bool
 }
 
 type RoleViewConfig struct {
 	ViewSlug string `yaml:"view"`
+	// Constraints restrict the input values the view is granted for, all have to hold
+	Constraints []InputConstraintConfig
+}
+
+// InputConstraintConfig restricts the values of an input to values or a regex, or by an expression like namespace in ["staging", "dev"]
+type InputConstraintConfig struct {
+	InputSlug  string `yaml:"input"`
+	Values     []string
+	Regex      string
+	Expression string
 }
 
 type RoleCategoryConfig struct {
@@ -49,30 +98,125 @@ type CategoryConfig struct {
 	Slug  string
 	Name  string
 	Color string
+	// Parent is the slug of the category the category is nested in
+	Parent string
+	// Order sorts categories with the same parent ascending, categories of equal order keep their yaml order
+	Order int
 }
 
 type ViewConfig struct {
-	Slug         string
-	Name         string
-	CommandSlug  string `yaml:"command"`
-	SequenceSlug string `yaml:"sequence"`
-	CategorySlug string `yaml:"category"`
+	Slug             string
+	Name             string
+	Description      string
+	CommandSlug      string          `yaml:"command"`
+	SequenceSlug     string          `yaml:"sequence"`
+	CategorySlug     string          `yaml:"category"`
+	RequiresApproval *ApprovalConfig `yaml:"requiresApproval"`
+	// Inputs preset values of inputs of the command of the view
+	Inputs []ViewInputConfig
+	// Order sorts views ascending, views of equal order keep their yaml order
+	Order int
+	Tags  []string
+}
+
+// ViewInputConfig presets the value of an input, a locked value can't be changed by users of the view
+type ViewInputConfig struct {
+	InputSlug string `yaml:"input"`
+	Value     string
+	Locked    bool
 }
 
 type SequenceConfig struct {
-	Slug  string
-	Steps []StepConfig
+	Slug      string
+	Steps     []StepConfig
+	OnFailure []StepConfig `yaml:"onFailure"`
+	Finally   []StepConfig
 }
 
 type StepConfig struct {
-	Name        string
-	CommandSlug string `yaml:"command"`
+	Slug            string
+	Name            string
+	CommandSlug     string `yaml:"command"`
+	SequenceSlug    string `yaml:"sequence"`
+	Needs           []string
+	Foreach         *StepForeachConfig
+	Retries         int
+	Backoff         time.Duration
+	ContinueOnError bool `yaml:"continueOnError"`
+	When            []StepConditionConfig
+	Inputs          []StepInputConfig
+	Outputs         []StepOutputConfig
+}
+
+type StepForeachConfig struct {
+	InputSlug   string `yaml:"input"`
+	Values      []string
+	As          string
+	Parallel    bool
+	MaxParallel int `yaml:"maxParallel"`
+}
+
+type StepConditionConfig struct {
+	StepSlug  string `yaml:"step"`
+	ExitCodes []int  `yaml:"exitCodes"`
+}
+
+type StepInputConfig struct {
+	InputSlug string `yaml:"input"`
+	Output    string
+	FromInput string `yaml:"fromInput"`
+}
+
+type StepOutputConfig struct {
+	Name     string
+	Regex    string
+	JSONPath string `yaml:"jsonPath"`
 }
 
 type CommandConfig struct {
-	Slug    string
-	Command string
-	Inputs  []CommandInputConfig
+	Slug string
+	// Command is not interpolated, so that it can use shell variables
+	Command          string `interpolate:"false"`
+	Description      string
+	Inputs           []CommandInputConfig
+	Secrets          []CommandSecretConfig
+	Redact           []RedactConfig
+	RequiresApproval *ApprovalConfig `yaml:"requiresApproval"`
+}
+
+// RedactConfig redacts matches of either a regex or a built-in detector
+type RedactConfig struct {
+	Regex    string
+	Detector string
+	// Replacement may reference groups of the regex, it defaults to ***
+	Replacement string
+}
+
+const defaultRedactReplacement = "***"
+
+// redactDetectors are the built-in regexes of common token formats
+var redactDetectors = map[string]string{
+	"aws-access-key": `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	"github-token":   `\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	"slack-token":    `\bxox[abprs]-[A-Za-z0-9-]{10,}\b`,
+	"jwt":            `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	"bearer":         `(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`,
+	"private-key":    `(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+type CommandSecretConfig struct {
+	SecretSlug string `yaml:"secret"`
+	// Env is the name of the env var the secret is injected as, it defaults to the secret slug
+	Env string
+}
+
+type ApprovalConfig struct {
+	Roles  []ApprovalRoleConfig
+	Expiry time.Duration
+}
+
+type ApprovalRoleConfig struct {
+	RoleSlug string `yaml:"role"`
 }
 
 type CommandInputConfig struct {
@@ -81,21 +225,48 @@ type CommandInputConfig struct {
 
 type InputConfig struct {
 	Slug string
+	// Type is one of string, int, bool, enum, multiline, date or secret, it defaults to string
+	Type        string
+	Default     string
+	Label       string
+	Description string
+	Placeholder string
+	// Values are the values an input of type enum accepts
+	Values []string
+	// OptionsFrom names the command that lists the values the input accepts
+	OptionsFrom string `yaml:"optionsFrom"`
+	// OptionsTTL is how long listed options are cached, options are not cached if it is zero
+	OptionsTTL time.Duration `yaml:"optionsTTL"`
 }
 
 func generateConfigs(conf ShellpaneConfig) (
 	map[string]domain.UserConfig,
+	map[string]domain.GroupConfig,
 	[]domain.ViewConfig,
 	[]domain.CategoryConfig,
 	map[string]domain.CommandConfig,
+	map[string]domain.SequenceConfig,
 	map[string]map[string]struct{},
 	map[string]map[string]struct{},
 	map[string]map[string]struct{},
 ) {
 	inputsM := map[string]domain.InputConfig{}
 	for _, i := range conf.Inputs {
+		inputType := i.Type
+		if inputType == "" {
+			inputType = domain.InputTypeString
+		}
+
 		inputsM[i.Slug] = domain.InputConfig{
-			Slug: i.Slug,
+			Slug:               i.Slug,
+			Type:               inputType,
+			Default:            i.Default,
+			Label:              i.Label,
+			Description:        i.Description,
+			Placeholder:        i.Placeholder,
+			Values:             i.Values,
+			OptionsCommandSlug: i.OptionsFrom,
+			OptionsTTL:         i.OptionsTTL,
 		}
 	}
 
@@ -108,35 +279,132 @@ func generateConfigs(conf ShellpaneConfig) (
 			})
 		}
 
+		var commandSecrets []domain.CommandSecretConfig
+		for _, s := range c.Secrets {
+			commandSecrets = append(commandSecrets, domain.CommandSecretConfig{
+				SecretSlug: s.SecretSlug,
+				Env:        secretEnv(s),
+			})
+		}
+
 		commandsM[c.Slug] = domain.CommandConfig{
-			Slug:    c.Slug,
-			Command: c.Command,
-			Inputs:  commandInputs,
+			Slug:             c.Slug,
+			Command:          c.Command,
+			Description:      c.Description,
+			Inputs:           commandInputs,
+			Secrets:          commandSecrets,
+			RedactRules:      generateRedactRules(append(append([]RedactConfig{}, conf.Redact...), c.Redact...)),
+			RequiresApproval: generateApproval(c.RequiresApproval),
 		}
 	}
 
-	processesM := map[string]domain.SequenceConfig{}
+	sequenceConfigsM := map[string]SequenceConfig{}
 	for _, p := range conf.Sequences {
+		sequenceConfigsM[p.Slug] = p
+	}
+
+	// sequences are generated on first use, as steps may include sequences that are defined later
+	processesM := map[string]domain.SequenceConfig{}
+	var generateSteps func(stepConfigs []StepConfig) []domain.StepConfig
+	generateSequence := func(slug string) domain.SequenceConfig {
+		if slug == "" {
+			return domain.SequenceConfig{}
+		}
+
+		sequence, ok := processesM[slug]
+		if ok {
+			return sequence
+		}
+
+		p := sequenceConfigsM[slug]
+		sequence = domain.SequenceConfig{
+			Slug:      p.Slug,
+			Steps:     generateSteps(p.Steps),
+			OnFailure: generateSteps(p.OnFailure),
+			Finally:   generateSteps(p.Finally),
+		}
+		processesM[slug] = sequence
+
+		return sequence
+	}
+
+	generateSteps = func(stepConfigs []StepConfig) []domain.StepConfig {
 		var steps []domain.StepConfig
-		for _, s := range p.Steps {
+		for _, s := range stepConfigs {
+			var when []domain.StepConditionConfig
+			for _, c := range s.When {
+				when = append(when, domain.StepConditionConfig{
+					StepSlug:  c.StepSlug,
+					ExitCodes: c.ExitCodes,
+				})
+			}
+
+			var inputs []domain.StepInputConfig
+			for _, i := range s.Inputs {
+				stepSlug, outputName := splitOutputReference(i.Output)
+				if i.FromInput != "" {
+					stepSlug, outputName = "", ""
+				}
+
+				inputs = append(inputs, domain.StepInputConfig{
+					InputSlug:  i.InputSlug,
+					StepSlug:   stepSlug,
+					OutputName: outputName,
+					FromInput:  i.FromInput,
+				})
+			}
+
+			var outputs []domain.StepOutputConfig
+			for _, o := range s.Outputs {
+				outputs = append(outputs, domain.StepOutputConfig{
+					Name:     o.Name,
+					Regex:    o.Regex,
+					JSONPath: o.JSONPath,
+				})
+			}
+
+			var foreach *domain.StepForeachConfig
+			if s.Foreach != nil {
+				foreach = &domain.StepForeachConfig{
+					InputSlug:   s.Foreach.InputSlug,
+					Values:      s.Foreach.Values,
+					As:          s.Foreach.As,
+					Parallel:    s.Foreach.Parallel,
+					MaxParallel: s.Foreach.MaxParallel,
+				}
+			}
+
 			steps = append(steps, domain.StepConfig{
-				Name:    s.Name,
-				Command: commandsM[s.CommandSlug],
+				Slug:            s.Slug,
+				Name:            s.Name,
+				Command:         commandsM[s.CommandSlug],
+				Sequence:        generateSequence(s.SequenceSlug),
+				Needs:           s.Needs,
+				Foreach:         foreach,
+				Retries:         s.Retries,
+				Backoff:         s.Backoff,
+				ContinueOnError: s.ContinueOnError,
+				When:            when,
+				Inputs:          inputs,
+				Outputs:         outputs,
 			})
 		}
 
-		processesM[p.Slug] = domain.SequenceConfig{
-			Slug:  p.Slug,
-			Steps: steps,
-		}
+		return steps
+	}
+
+	for _, p := range conf.Sequences {
+		generateSequence(p.Slug)
 	}
 
 	categoriesM := map[string]domain.CategoryConfig{}
 	for _, c := range conf.Categories {
 		category := domain.CategoryConfig{
-			Slug:  c.Slug,
-			Name:  c.Name,
-			Color: c.Color,
+			Slug:   c.Slug,
+			Name:   c.Name,
+			Color:  c.Color,
+			Parent: c.Parent,
+			Order:  c.Order,
 		}
 
 		categoriesM[c.Slug] = category
@@ -145,12 +413,26 @@ func generateConfigs(conf ShellpaneConfig) (
 	views := []domain.ViewConfig{}
 	viewsM := map[string]domain.ViewConfig{}
 	for _, v := range conf.Views {
+		var viewInputs []domain.ViewInputConfig
+		for _, i := range v.Inputs {
+			viewInputs = append(viewInputs, domain.ViewInputConfig{
+				InputSlug: i.InputSlug,
+				Value:     i.Value,
+				Locked:    i.Locked,
+			})
+		}
+
 		view := domain.ViewConfig{
-			Slug:     v.Slug,
-			Name:     v.Name,
-			Command:  commandsM[v.CommandSlug],
-			Sequence: processesM[v.SequenceSlug],
-			Category: categoriesM[v.CategorySlug],
+			Slug:             v.Slug,
+			Name:             v.Name,
+			Description:      v.Description,
+			Command:          commandsM[v.CommandSlug],
+			Sequence:         processesM[v.SequenceSlug],
+			Category:         categoriesM[v.CategorySlug],
+			RequiresApproval: generateApproval(v.RequiresApproval),
+			Inputs:           viewInputs,
+			Order:            v.Order,
+			Tags:             v.Tags,
 		}
 
 		c := categoriesM[v.CategorySlug]
@@ -161,11 +443,57 @@ func generateConfigs(conf ShellpaneConfig) (
 		views = append(views, view)
 	}
 
-	categories := []domain.CategoryConfig{}
-	for _, c := range conf.Categories {
-		categories = append(categories, categoriesM[c.Slug])
+	// a view that requires approval requires it for every command it runs
+	for _, v := range views {
+		if v.RequiresApproval == nil {
+			continue
+		}
+
+		slugs := v.Sequence.CommandSlugs()
+		if v.Command.Slug != "" {
+			slugs[v.Command.Slug] = struct{}{}
+		}
+
+		for slug := range slugs {
+			c := commandsM[slug]
+			c.RequiresApproval = mergeApprovals(c.RequiresApproval, v.RequiresApproval)
+			commandsM[slug] = c
+		}
 	}
 
+	sort.SliceStable(views, func(i, j int) bool {
+		return views[i].Order < views[j].Order
+	})
+
+	// categories are nested in their parents, so that granting a category grants the categories nested in it
+	categoryTreesM := map[string]domain.CategoryConfig{}
+	var generateCategoryTree func(parent string) []domain.CategoryConfig
+	generateCategoryTree = func(parent string) []domain.CategoryConfig {
+		categories := []domain.CategoryConfig{}
+		for _, c := range conf.Categories {
+			if c.Parent != parent {
+				continue
+			}
+
+			category := categoriesM[c.Slug]
+			sort.SliceStable(category.Views, func(i, j int) bool {
+				return category.Views[i].Order < category.Views[j].Order
+			})
+			category.Categories = generateCategoryTree(c.Slug)
+
+			categoryTreesM[c.Slug] = category
+			categories = append(categories, category)
+		}
+
+		sort.SliceStable(categories, func(i, j int) bool {
+			return categories[i].Order < categories[j].Order
+		})
+
+		return categories
+	}
+
+	categories := generateCategoryTree("")
+
 	rolesM := map[string]domain.RoleConfig{}
 	for _, r := range conf.Roles {
 		var views []domain.ViewConfig
@@ -175,13 +503,29 @@ func generateConfigs(conf ShellpaneConfig) (
 
 		var categories []domain.CategoryConfig
 		for _, c := range r.Categories {
-			categories = append(categories, categoriesM[c.CategorySlug])
+			categories = append(categories, categoryTreesM[c.CategorySlug])
+		}
+
+		var viewGrants []domain.ViewGrant
+		for _, v := range r.Views {
+			var constraints []domain.InputConstraint
+			for _, c := range v.Constraints {
+				constraint, _ := generateInputConstraint(c)
+				constraints = append(constraints, constraint)
+			}
+
+			viewGrants = append(viewGrants, domain.ViewGrant{
+				ViewSlug:    v.ViewSlug,
+				Constraints: constraints,
+			})
 		}
 
 		rolesM[r.Slug] = domain.RoleConfig{
 			Slug:       r.Slug,
 			Views:      views,
 			Categories: categories,
+			Admin:      r.Admin,
+			ViewGrants: viewGrants,
 		}
 	}
 
@@ -193,8 +537,9 @@ func generateConfigs(conf ShellpaneConfig) (
 		}
 
 		groupsM[r.Slug] = domain.GroupConfig{
-			Slug:  r.Slug,
-			Roles: roles,
+			Slug:         r.Slug,
+			Roles:        roles,
+			HeaderGroups: r.HeaderGroups,
 		}
 	}
 
@@ -212,64 +557,195 @@ func generateConfigs(conf ShellpaneConfig) (
 	}
 
 	allowedCommands := map[string]map[string]struct{}{}
+	allowedViews := map[string]map[string]struct{}{}
+	allowedCategories := map[string]map[string]struct{}{}
 	for userID, user := range usersM {
-		allowedCommands[userID] = map[string]struct{}{}
-		for i := range user.Groups {
-			for ii := range user.Groups[i].Roles {
-				for _, v := range user.Groups[i].Roles[ii].Views {
-					allowedCommands[userID][v.Command.Slug] = struct{}{}
-
-					for _, s := range v.Sequence.Steps {
-						allowedCommands[userID][s.Command.Slug] = struct{}{}
-					}
-				}
+		allowedCommands[userID] = user.AllowedCommands()
+		allowedViews[userID] = user.AllowedViews()
+		allowedCategories[userID] = user.AllowedCategories()
+	}
 
-				for _, c := range user.Groups[i].Roles[ii].Categories {
-					for _, v := range c.Views {
-						allowedCommands[userID][v.Command.Slug] = struct{}{}
+	return usersM, groupsM, views, categories, commandsM, processesM, allowedCategories, allowedViews, allowedCommands
+}
 
-						for _, s := range v.Sequence.Steps {
-							allowedCommands[userID][s.Command.Slug] = struct{}{}
-						}
-					}
-				}
-			}
+// generateAPITokens returns the api tokens by their hash
+func generateAPITokens(tokens []APITokenConfig) map[string]domain.APIToken {
+	tokensM := map[string]domain.APIToken{}
+	for _, t := range tokens {
+		hash := strings.ToLower(t.Hash)
+
+		tokensM[hash] = domain.APIToken{
+			Slug:         t.Slug,
+			Owner:        t.Owner,
+			Hash:         hash,
+			ExpiresAt:    t.ExpiresAt,
+			CommandSlugs: t.Commands,
+			ViewSlugs:    t.Views,
 		}
 	}
 
-	allowedViews := map[string]map[string]struct{}{}
-	for userID, user := range usersM {
-		allowedViews[userID] = map[string]struct{}{}
-		for i := range user.Groups {
-			for ii := range user.Groups[i].Roles {
-				for _, v := range user.Groups[i].Roles[ii].Views {
-					allowedViews[userID][v.Slug] = struct{}{}
-				}
+	return tokensM
+}
 
-				for _, c := range user.Groups[i].Roles[ii].Categories {
-					for _, v := range c.Views {
-						allowedViews[userID][v.Slug] = struct{}{}
-					}
-				}
+// constraintExpressionRegexp matches expressions of the form <input> <operator> <json value>
+var constraintExpressionRegexp = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+)\s*(==|!=|\bnot\s+in\b|\bin\b|\bmatches\b)\s*(.+?)\s*$`)
+
+// generateInputConstraint returns the constraint of c, an expression is parsed
+// into the input and either values or a regex
+func generateInputConstraint(c InputConstraintConfig) (domain.InputConstraint, error) {
+	if c.Expression == "" {
+		return domain.NewInputConstraint(c.InputSlug, c.Values, c.Regex, false)
+	}
+
+	m := constraintExpressionRegexp.FindStringSubmatch(c.Expression)
+	if m == nil {
+		return domain.InputConstraint{}, errors.Errorf("expression=%v is not of the form <input> <in|not in|==|!=|matches> <value>", c.Expression)
+	}
+
+	operator := strings.Join(strings.Fields(m[2]), " ")
+
+	var value interface{}
+	d := json.NewDecoder(strings.NewReader(m[3]))
+	d.UseNumber()
+	err := d.Decode(&value)
+	if err != nil || d.More() {
+		return domain.InputConstraint{}, errors.Errorf("value=%v of expression=%v is not a json value", m[3], c.Expression)
+	}
+
+	var values []string
+	var regex string
+	switch operator {
+	case "in", "not in":
+		array, ok := value.([]interface{})
+		if !ok {
+			return domain.InputConstraint{}, errors.Errorf("value=%v of expression=%v is not an array", m[3], c.Expression)
+		}
+
+		values = []string{}
+		for _, v := range array {
+			s, ok := constraintValue(v)
+			if !ok {
+				return domain.InputConstraint{}, errors.Errorf("value=%v of expression=%v is not a string or number", v, c.Expression)
 			}
+
+			values = append(values, s)
+		}
+	case "==", "!=":
+		s, ok := constraintValue(value)
+		if !ok {
+			return domain.InputConstraint{}, errors.Errorf("value=%v of expression=%v is not a string or number", m[3], c.Expression)
 		}
+
+		values = []string{s}
+	case "matches":
+		s, ok := value.(string)
+		if !ok {
+			return domain.InputConstraint{}, errors.Errorf("value=%v of expression=%v is not a string", m[3], c.Expression)
+		}
+
+		regex = s
 	}
 
-	allowedCategories := map[string]map[string]struct{}{}
-	for userID, user := range usersM {
-		allowedCategories[userID] = map[string]struct{}{}
-		for i := range user.Groups {
-			for ii := range user.Groups[i].Roles {
-				for _, c := range user.Groups[i].Roles[ii].Categories {
-					allowedCategories[userID][c.Slug] = struct{}{}
-				}
+	return domain.NewInputConstraint(m[1], values, regex, operator == "not in" || operator == "!=")
+}
 
-				for _, v := range user.Groups[i].Roles[ii].Views {
-					allowedCategories[userID][v.Category.Slug] = struct{}{}
-				}
-			}
+func constraintValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// splitOutputReference splits a reference of the form <step slug>.<output name>
+func splitOutputReference(ref string) (string, string) {
+	i := strings.LastIndex(ref, ".")
+	if i == -1 {
+		return "", ref
+	}
+
+	return ref[:i], ref[i+1:]
+}
+
+const defaultApprovalExpiry = time.Hour
+
+func generateApproval(approval *ApprovalConfig) *domain.ApprovalConfig {
+	if approval == nil {
+		return nil
+	}
+
+	var roleSlugs []string
+	for _, r := range approval.Roles {
+		roleSlugs = append(roleSlugs, r.RoleSlug)
+	}
+
+	expiry := approval.Expiry
+	if expiry == 0 {
+		expiry = defaultApprovalExpiry
+	}
+
+	return &domain.ApprovalConfig{
+		RoleSlugs: roleSlugs,
+		Expiry:    expiry,
+	}
+}
+
+// mergeApprovals returns an approval that any approver of a or b may give and that expires with the earlier of both
+func mergeApprovals(a *domain.ApprovalConfig, b *domain.ApprovalConfig) *domain.ApprovalConfig {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+
+	merged := domain.ApprovalConfig{Expiry: a.Expiry}
+	if b.Expiry < merged.Expiry {
+		merged.Expiry = b.Expiry
+	}
+
+	seen := map[string]struct{}{}
+	for _, slug := range append(append([]string{}, a.RoleSlugs...), b.RoleSlugs...) {
+		_, ok := seen[slug]
+		if ok {
+			continue
+		}
+
+		seen[slug] = struct{}{}
+		merged.RoleSlugs = append(merged.RoleSlugs, slug)
+	}
+
+	return &merged
+}
+
+func secretEnv(s CommandSecretConfig) string {
+	if s.Env != "" {
+		return s.Env
+	}
+
+	return s.SecretSlug
+}
+
+func generateRedactRules(redacts []RedactConfig) []domain.RedactRule {
+	var rules []domain.RedactRule
+	for _, r := range redacts {
+		regex := r.Regex
+		if r.Detector != "" {
+			regex = redactDetectors[r.Detector]
 		}
+
+		replacement := r.Replacement
+		if replacement == "" {
+			replacement = defaultRedactReplacement
+		}
+
+		// the regexes of the rules and detectors are validated, so they compile
+		rule, _ := domain.NewRedactRule(regex, replacement)
+		rules = append(rules, rule)
 	}
 
-	return usersM, views, categories, commandsM, allowedCategories, allowedViews, allowedCommands
+	return rules
 }
@@ -0,0 +1,145 @@
+package bootstrap
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// interpolationRegexp matches ${VAR}, ${VAR:-default} and ${file:/path}, $${ escapes a literal ${
+var interpolationRegexp = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// interpolate replaces ${VAR} and ${VAR:-default} with the value of the env var VAR and ${file:/path}
+// with the content of the file in the scalar values of node. Fields of t that are tagged interpolate:"false",
+// e.g. command strings that use shell variables, are left as they are.
+func interpolate(fs afero.Fs, node *yaml.Node, t reflect.Type) []locatedError {
+	return interpolateNode(fs, node, t, map[*yaml.Node]struct{}{})
+}
+
+func interpolateNode(fs afero.Fs, node *yaml.Node, t reflect.Type, visited map[*yaml.Node]struct{}) []locatedError {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// anchors are interpolated once, even if aliases refer to them multiple times
+	_, ok := visited[node]
+	if ok {
+		return nil
+	}
+	visited[node] = struct{}{}
+
+	var errs []locatedError
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for i := range node.Content {
+			errs = append(errs, interpolateNode(fs, node.Content[i], t, visited)...)
+		}
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+
+		for i := range node.Content {
+			errs = append(errs, interpolateNode(fs, node.Content[i], t.Elem(), visited)...)
+		}
+	case yaml.MappingNode:
+		if t.Kind() == reflect.Map {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				errs = append(errs, interpolateNode(fs, node.Content[i+1], t.Elem(), visited)...)
+			}
+
+			return errs
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			field, ok := fields[node.Content[i].Value]
+			if !ok || field.Tag.Get("interpolate") == "false" {
+				continue
+			}
+
+			errs = append(errs, interpolateNode(fs, node.Content[i+1], field.Type, visited)...)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return nil
+		}
+
+		value, err := interpolateString(fs, node.Value)
+		if err != nil {
+			return []locatedError{{line: node.Line, column: node.Column, msg: err.Error()}}
+		}
+
+		node.Value = value
+		// plain scalars are resolved again, so that e.g. ${RETRIES} can be decoded into an int
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+
+	return errs
+}
+
+func interpolateString(fs afero.Fs, s string) (string, error) {
+	var err error
+	value := interpolationRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" || err != nil {
+			return "${"
+		}
+
+		var value string
+		value, err = resolveInterpolation(fs, interpolationRegexp.FindStringSubmatch(match)[1])
+
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+func resolveInterpolation(fs afero.Fs, expr string) (string, error) {
+	if strings.HasPrefix(expr, "file:") {
+		path := strings.TrimPrefix(expr, "file:")
+
+		f, err := fs.Open(path)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to open interpolated file=%v", path)
+		}
+		defer f.Close()
+
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read interpolated file=%v", path)
+		}
+
+		return strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r"), nil
+	}
+
+	name, defaultValue, hasDefault := strings.Cut(expr, ":-")
+	if !envNameRegexp.MatchString(name) {
+		return "", errors.Errorf("invalid interpolation=${%v}, expected ${VAR}, ${VAR:-default} or ${file:/path}", expr)
+	}
+
+	value, ok := os.LookupEnv(name)
+	switch {
+	case (!ok || value == "") && hasDefault:
+		return defaultValue, nil
+	case !ok:
+		return "", errors.Errorf("unresolved variable=%v, set the env var or use ${%v:-default}", name, name)
+	default:
+		return value, nil
+	}
+}
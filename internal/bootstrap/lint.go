@@ -0,0 +1,202 @@
+package bootstrap
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// commandVarRegex matches $VAR and ${VAR...} references of shell commands
+var commandVarRegex = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// commandAssignRegex matches variables that a shell command assigns itself, e.g. X=1, for X in, read X
+var commandAssignRegex = regexp.MustCompile(`(?:^|[\s;&|(])(?:([A-Za-z_][A-Za-z0-9_]*)=|for\s+([A-Za-z_][A-Za-z0-9_]*)\s+in\b|read\s+(?:-[a-z]+\s+)*([A-Za-z_][A-Za-z0-9_]*))`)
+
+// lintEnvVars are env vars that commands may read without declaring them, as they are set by the shell or the system
+var lintEnvVars = map[string]struct{}{
+	"HOME": {}, "PATH": {}, "USER": {}, "PWD": {}, "OLDPWD": {}, "SHELL": {}, "HOSTNAME": {}, "TMPDIR": {},
+	"LANG": {}, "TERM": {}, "IFS": {}, "RANDOM": {}, "SECONDS": {}, "LINENO": {}, "UID": {}, "EUID": {}, "PPID": {},
+	"BASH_SOURCE": {}, "REPLY": {}, "OPTARG": {}, "OPTIND": {},
+}
+
+// LintShellpaneConfig returns warnings about parts of a valid config that are likely mistakes,
+// e.g. configs that are never used or commands that read env vars they don't declare as inputs
+func LintShellpaneConfig(config ShellpaneConfig) []string {
+	var warnings []string
+
+	usedCommands := map[string]struct{}{}
+	usedInputs := map[string]struct{}{}
+	usedCategories := map[string]struct{}{}
+	usedRoles := map[string]struct{}{}
+	sequenceVars := map[string]struct{}{}
+
+	for _, view := range config.Views {
+		usedCommands[view.CommandSlug] = struct{}{}
+		usedCategories[view.CategorySlug] = struct{}{}
+	}
+
+	for _, sequence := range config.Sequences {
+		for _, steps := range [][]StepConfig{sequence.Steps, sequence.OnFailure, sequence.Finally} {
+			for _, step := range steps {
+				usedCommands[step.CommandSlug] = struct{}{}
+
+				if step.Foreach != nil {
+					sequenceVars[step.Foreach.As] = struct{}{}
+					usedInputs[step.Foreach.InputSlug] = struct{}{}
+				}
+
+				for _, input := range step.Inputs {
+					sequenceVars[input.InputSlug] = struct{}{}
+					usedInputs[input.FromInput] = struct{}{}
+				}
+
+				for _, output := range step.Outputs {
+					sequenceVars[output.Name] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for _, input := range config.Inputs {
+		usedCommands[input.OptionsFrom] = struct{}{}
+	}
+
+	// a category that only nests other categories is used if they are
+	for _, category := range config.Categories {
+		usedCategories[category.Parent] = struct{}{}
+	}
+
+	for _, command := range config.Commands {
+		for _, input := range command.Inputs {
+			usedInputs[input.InputSlug] = struct{}{}
+		}
+	}
+
+	for _, group := range config.Groups {
+		for _, role := range group.Roles {
+			usedRoles[role.RoleSlug] = struct{}{}
+		}
+	}
+
+	for _, command := range config.Commands {
+		_, ok := usedCommands[command.Slug]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("command=%v is not used by any view, sequence or input", command.Slug))
+		}
+	}
+
+	for _, input := range config.Inputs {
+		_, ok := usedInputs[input.Slug]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("input=%v is not used by any command", input.Slug))
+		}
+	}
+
+	for _, category := range config.Categories {
+		_, ok := usedCategories[category.Slug]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("category=%v has no views", category.Slug))
+		}
+	}
+
+	for _, role := range config.Roles {
+		_, ok := usedRoles[role.Slug]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("role=%v is not granted to any group", role.Slug))
+		}
+	}
+
+	// without roles permissions are not configured, so every view is visible
+	if len(config.Roles) > 0 {
+		grantedViews := map[string]struct{}{}
+		grantedCategories := map[string]struct{}{}
+		for _, role := range config.Roles {
+			for _, view := range role.Views {
+				grantedViews[view.ViewSlug] = struct{}{}
+			}
+
+			for _, category := range role.Categories {
+				grantedCategories[category.CategorySlug] = struct{}{}
+			}
+		}
+
+		// granting a category grants the categories nested in it
+		for granted := true; granted; {
+			granted = false
+			for _, category := range config.Categories {
+				_, parentOK := grantedCategories[category.Parent]
+				_, ok := grantedCategories[category.Slug]
+				if parentOK && !ok {
+					grantedCategories[category.Slug] = struct{}{}
+					granted = true
+				}
+			}
+		}
+
+		for _, view := range config.Views {
+			_, viewOK := grantedViews[view.Slug]
+			_, categoryOK := grantedCategories[view.CategorySlug]
+			if !viewOK && !categoryOK {
+				warnings = append(warnings, fmt.Sprintf("view=%v is not granted by any role", view.Slug))
+			}
+		}
+	}
+
+	for _, command := range config.Commands {
+		undeclared := undeclaredCommandVars(sequenceVars, command)
+		if len(undeclared) > 0 {
+			warnings = append(warnings, fmt.Sprintf("command=%v reads undeclared vars=%v", command.Slug, undeclared))
+		}
+	}
+
+	return warnings
+}
+
+// undeclaredCommandVars returns the sorted vars a command reads that are neither inputs, secrets,
+// vars provided by sequences, assigned by the command itself nor well-known env vars
+func undeclaredCommandVars(sequenceVars map[string]struct{}, command CommandConfig) []string {
+	declared := map[string]struct{}{}
+	for _, input := range command.Inputs {
+		declared[input.InputSlug] = struct{}{}
+	}
+
+	for _, secret := range command.Secrets {
+		declared[secretEnv(secret)] = struct{}{}
+	}
+
+	for _, m := range commandAssignRegex.FindAllStringSubmatch(command.Command, -1) {
+		for _, name := range m[1:] {
+			if name != "" {
+				declared[name] = struct{}{}
+			}
+		}
+	}
+
+	seen := map[string]struct{}{}
+	var undeclared []string
+	for _, m := range commandVarRegex.FindAllStringSubmatch(command.Command, -1) {
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+
+		_, ok := seen[name]
+		if ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		_, declaredOK := declared[name]
+		_, sequenceOK := sequenceVars[name]
+		_, envOK := lintEnvVars[name]
+		if declaredOK || sequenceOK || envOK {
+			continue
+		}
+
+		undeclared = append(undeclared, name)
+	}
+
+	sort.Strings(undeclared)
+
+	return undeclared
+}
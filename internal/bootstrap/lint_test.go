@@ -0,0 +1,109 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LintShellpaneConfig(t *testing.T) {
+	tcs := []struct {
+		name           string
+		config         ShellpaneConfig
+		expectWarnings []string
+	}{
+		{
+			name: "no warnings",
+			config: ShellpaneConfig{
+				Groups:     []GroupConfig{{Slug: "g", Roles: []GroupRoleConfig{{RoleSlug: "r"}}}},
+				Roles:      []RoleConfig{{Slug: "r", Categories: []RoleCategoryConfig{{CategorySlug: "c"}}}},
+				Categories: []CategoryConfig{{Slug: "c"}},
+				Views:      []ViewConfig{{Slug: "v", CommandSlug: "cmd", CategorySlug: "c"}},
+				Commands: []CommandConfig{
+					{
+						Slug:    "cmd",
+						Command: `X=1; for f in a b; do echo $X $f ${A:-} $TOKEN $HOME; done; echo '{print $1}'`,
+						Inputs:  []CommandInputConfig{{InputSlug: "A"}},
+						Secrets: []CommandSecretConfig{{SecretSlug: "TOKEN"}},
+					},
+				},
+				Inputs: []InputConfig{{Slug: "A"}},
+			},
+			expectWarnings: nil,
+		},
+		{
+			name: "unused configs",
+			config: ShellpaneConfig{
+				Roles:      []RoleConfig{{Slug: "r", Views: []RoleViewConfig{{ViewSlug: "v"}}}},
+				Categories: []CategoryConfig{{Slug: "c"}, {Slug: "empty"}},
+				Views: []ViewConfig{
+					{Slug: "v", CommandSlug: "cmd", CategorySlug: "c"},
+					{Slug: "ungranted", CommandSlug: "cmd", CategorySlug: "c"},
+				},
+				Commands: []CommandConfig{
+					{Slug: "cmd", Command: "echo"},
+					{Slug: "unused", Command: "echo"},
+				},
+				Inputs: []InputConfig{{Slug: "A"}},
+			},
+			expectWarnings: []string{
+				"command=unused is not used by any view, sequence or input",
+				"input=A is not used by any command",
+				"category=empty has no views",
+				"role=r is not granted to any group",
+				"view=ungranted is not granted by any role",
+			},
+		},
+		{
+			name: "nested categories",
+			config: ShellpaneConfig{
+				Groups:     []GroupConfig{{Slug: "g", Roles: []GroupRoleConfig{{RoleSlug: "r"}}}},
+				Roles:      []RoleConfig{{Slug: "r", Categories: []RoleCategoryConfig{{CategorySlug: "parent"}}}},
+				Categories: []CategoryConfig{{Slug: "parent"}, {Slug: "child", Parent: "parent"}},
+				Views:      []ViewConfig{{Slug: "v", CommandSlug: "cmd", CategorySlug: "child"}},
+				Commands:   []CommandConfig{{Slug: "cmd", Command: "echo"}},
+			},
+			expectWarnings: nil,
+		},
+		{
+			name: "undeclared vars",
+			config: ShellpaneConfig{
+				Categories: []CategoryConfig{{Slug: "c"}},
+				Views:      []ViewConfig{{Slug: "v", CommandSlug: "cmd", CategorySlug: "c"}},
+				Commands: []CommandConfig{
+					{Slug: "cmd", Command: "echo $B ${A} $A $B"},
+				},
+			},
+			expectWarnings: []string{
+				"command=cmd reads undeclared vars=[A B]",
+			},
+		},
+		{
+			name: "vars provided by sequences",
+			config: ShellpaneConfig{
+				Categories: []CategoryConfig{{Slug: "c"}},
+				Views:      []ViewConfig{{Slug: "v", SequenceSlug: "s", CategorySlug: "c"}},
+				Sequences: []SequenceConfig{
+					{
+						Slug: "s",
+						Steps: []StepConfig{
+							{Slug: "a", CommandSlug: "a", Outputs: []StepOutputConfig{{Name: "ID"}}},
+							{Slug: "b", CommandSlug: "b", Foreach: &StepForeachConfig{Values: []string{"x"}, As: "ITEM"}},
+						},
+					},
+				},
+				Commands: []CommandConfig{
+					{Slug: "a", Command: "echo 1"},
+					{Slug: "b", Command: "echo $ID $ITEM"},
+				},
+			},
+			expectWarnings: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectWarnings, LintShellpaneConfig(tc.config))
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package bootstrap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// configLoader merges the documents of shellpane yaml files and the files they include into one config
+// and remembers which file defined which slug
+type configLoader struct {
+	fs      afero.Fs
+	config  ShellpaneConfig
+	files   []string
+	loaded  map[string]struct{}
+	sources map[string]map[string][]string
+	// positions are the yaml nodes of the entities of config
+	positions configPositions
+	// decodeErrors are errors of the files that don't prevent decoding them,
+	// e.g. keys that don't exist in the config or variables that can't be interpolated
+	decodeErrors []locatedError
+}
+
+func newConfigLoader(fs afero.Fs) *configLoader {
+	return &configLoader{
+		fs:        fs,
+		loaded:    map[string]struct{}{},
+		sources:   map[string]map[string][]string{},
+		positions: configPositions{},
+	}
+}
+
+// readShellpaneConfig reads the shellpane config from a yaml file, all yaml files of a directory or all files matching a glob
+func readShellpaneConfig(fs afero.Fs, path string) (ShellpaneConfig, []string, error) {
+	l := newConfigLoader(fs)
+
+	err := l.read(path)
+	if err != nil {
+		return ShellpaneConfig{}, l.files, err
+	}
+
+	return l.config, l.files, nil
+}
+
+// LoadShellpaneConfig reads the shellpane config from a yaml file, all yaml files of a directory or all files matching a glob
+// and validates it, validation errors are reported one per line as file:line:col: message
+func LoadShellpaneConfig(fs afero.Fs, path string) (ShellpaneConfig, error) {
+	l := newConfigLoader(fs)
+
+	err := l.read(path)
+	if err != nil {
+		return ShellpaneConfig{}, err
+	}
+
+	err = l.validate()
+	if err != nil {
+		return ShellpaneConfig{}, err
+	}
+
+	return l.config, nil
+}
+
+func (l *configLoader) read(path string) error {
+	paths, err := resolveConfigPaths(l.fs, path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve path=%v", path)
+	}
+
+	for _, p := range paths {
+		err := l.loadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file=%v", p)
+		}
+	}
+
+	return nil
+}
+
+// validate validates the merged config and reports the validation errors, duplicate ids and unknown keys
+// at their position in the files the config was read from
+func (l *configLoader) validate() error {
+	located := append([]locatedError{}, l.decodeErrors...)
+
+	duplicates, duplicatePaths := l.duplicateErrors()
+	located = append(located, duplicates...)
+
+	err := ValidateShellpaneConfig(l.config)
+	if err != nil {
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			return err
+		}
+
+		var remaining ValidationErrors
+		for _, e := range errs {
+			_, ok := duplicatePaths[e.Path]
+			if ok && strings.HasPrefix(e.Err.Error(), "duplicate ") {
+				continue
+			}
+
+			remaining = append(remaining, e)
+		}
+
+		located = append(located, l.positions.locateErrors(remaining)...)
+	}
+
+	return joinLocatedErrors(located)
+}
+
+// resolveConfigPaths returns the yaml files of a directory, the files matching a glob or the path itself, sorted by path
+func resolveConfigPaths(fs afero.Fs, path string) ([]string, error) {
+	if strings.ContainsAny(path, "*?[") {
+		paths, err := afero.Glob(fs, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to glob")
+		}
+
+		if len(paths) == 0 {
+			return nil, errors.Errorf("no files match glob=%v", path)
+		}
+
+		sort.Strings(paths)
+
+		return paths, nil
+	}
+
+	info, err := fs.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat path")
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var paths []string
+	err = afero.Walk(fs, path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		ext := filepath.Ext(p)
+		if !info.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			paths = append(paths, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to walk directory")
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+func (l *configLoader) loadFile(path string) error {
+	path = filepath.Clean(path)
+	_, loaded := l.loaded[path]
+	if loaded {
+		return nil
+	}
+	l.loaded[path] = struct{}{}
+	l.files = append(l.files, path)
+
+	f, err := l.fs.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file")
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read file")
+	}
+
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var node yaml.Node
+		err := d.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to yaml unmarshal content=%v", string(b))
+		}
+
+		errs := append(interpolate(l.fs, &node, reflect.TypeOf(ShellpaneConfig{})), unknownKeys(&node, reflect.TypeOf(ShellpaneConfig{}), "")...)
+		for _, e := range errs {
+			e.file = path
+			l.decodeErrors = append(l.decodeErrors, e)
+		}
+
+		var config ShellpaneConfig
+		err = node.Decode(&config)
+		if err != nil {
+			return errors.Wrapf(err, "failed to yaml unmarshal content=%v", string(b))
+		}
+
+		err = l.merge(path, filepath.Dir(path), config, &node)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// merge appends the entities of config and loads the files it includes relative to dir,
+// doc is the yaml document config was decoded from or nil if config wasn't read from a file
+func (l *configLoader) merge(source string, dir string, config ShellpaneConfig, doc *yaml.Node) error {
+	for i := range config.Users {
+		l.addSource("user", config.Users[i].ID, source)
+	}
+	for i := range config.Groups {
+		l.addSource("group", config.Groups[i].Slug, source)
+	}
+	for i := range config.Roles {
+		l.addSource("role", config.Roles[i].Slug, source)
+	}
+	for i := range config.Categories {
+		l.addSource("category", config.Categories[i].Slug, source)
+	}
+	for i := range config.Views {
+		l.addSource("view", config.Views[i].Slug, source)
+	}
+	for i := range config.Sequences {
+		l.addSource("sequence", config.Sequences[i].Slug, source)
+	}
+	for i := range config.Commands {
+		l.addSource("command", config.Commands[i].Slug, source)
+	}
+	for i := range config.Inputs {
+		l.addSource("input", config.Inputs[i].Slug, source)
+	}
+	for i := range config.Secrets {
+		l.addSource("secret", config.Secrets[i].Slug, source)
+	}
+	for i := range config.Tokens {
+		l.addSource("token", config.Tokens[i].Slug, source)
+	}
+
+	l.positions.add(source, doc, map[string]int{
+		"users":      len(config.Users),
+		"groups":     len(config.Groups),
+		"roles":      len(config.Roles),
+		"categories": len(config.Categories),
+		"views":      len(config.Views),
+		"sequences":  len(config.Sequences),
+		"commands":   len(config.Commands),
+		"inputs":     len(config.Inputs),
+		"secrets":    len(config.Secrets),
+		"redact":     len(config.Redact),
+		"tokens":     len(config.Tokens),
+	})
+
+	l.config.Users = append(l.config.Users, config.Users...)
+	l.config.Groups = append(l.config.Groups, config.Groups...)
+	l.config.Roles = append(l.config.Roles, config.Roles...)
+	l.config.Categories = append(l.config.Categories, config.Categories...)
+	l.config.Views = append(l.config.Views, config.Views...)
+	l.config.Sequences = append(l.config.Sequences, config.Sequences...)
+	l.config.Commands = append(l.config.Commands, config.Commands...)
+	l.config.Inputs = append(l.config.Inputs, config.Inputs...)
+	l.config.Secrets = append(l.config.Secrets, config.Secrets...)
+	l.config.Redact = append(l.config.Redact, config.Redact...)
+	l.config.Tokens = append(l.config.Tokens, config.Tokens...)
+
+	for _, include := range config.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(dir, include)
+		}
+
+		paths, err := resolveConfigPaths(l.fs, include)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve include=%v of file=%v", include, source)
+		}
+
+		for _, p := range paths {
+			err := l.loadFile(p)
+			if err != nil {
+				return errors.Wrapf(err, "failed to load file=%v included by file=%v", p, source)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *configLoader) addSource(kind string, slug string, source string) {
+	if l.sources[kind] == nil {
+		l.sources[kind] = map[string][]string{}
+	}
+
+	l.sources[kind][slug] = append(l.sources[kind][slug], source)
+}
+
+// entityIDs returns the ids of the entities of each kind of the merged config in the order of its positions
+func (l *configLoader) entityIDs() map[string][]string {
+	ids := map[string][]string{}
+	for _, u := range l.config.Users {
+		ids["users"] = append(ids["users"], u.ID)
+	}
+	for _, g := range l.config.Groups {
+		ids["groups"] = append(ids["groups"], g.Slug)
+	}
+	for _, r := range l.config.Roles {
+		ids["roles"] = append(ids["roles"], r.Slug)
+	}
+	for _, c := range l.config.Categories {
+		ids["categories"] = append(ids["categories"], c.Slug)
+	}
+	for _, v := range l.config.Views {
+		ids["views"] = append(ids["views"], v.Slug)
+	}
+	for _, s := range l.config.Sequences {
+		ids["sequences"] = append(ids["sequences"], s.Slug)
+	}
+	for _, c := range l.config.Commands {
+		ids["commands"] = append(ids["commands"], c.Slug)
+	}
+	for _, i := range l.config.Inputs {
+		ids["inputs"] = append(ids["inputs"], i.Slug)
+	}
+	for _, s := range l.config.Secrets {
+		ids["secrets"] = append(ids["secrets"], s.Slug)
+	}
+	for _, t := range l.config.Tokens {
+		ids["tokens"] = append(ids["tokens"], t.Slug)
+	}
+
+	return ids
+}
+
+// duplicateErrors reports every definition of an id that is defined more than once at its position,
+// together with the positions of the other definitions. It also returns the paths of the definitions,
+// so that the errors of the validation about the same duplicates can be left out.
+func (l *configLoader) duplicateErrors() ([]locatedError, map[string]struct{}) {
+	var located []locatedError
+	paths := map[string]struct{}{}
+	entityIDs := l.entityIDs()
+	keys := make([]string, 0, len(entityIDs))
+	for key := range entityIDs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		idKey := "slug"
+		if key == "users" {
+			idKey = "id"
+		}
+
+		var order []string
+		indices := map[string][]int{}
+		for i, id := range entityIDs[key] {
+			if id == "" {
+				continue
+			}
+
+			if len(indices[id]) == 0 {
+				order = append(order, id)
+			}
+			indices[id] = append(indices[id], i)
+		}
+
+		for _, id := range order {
+			is := indices[id]
+			if len(is) < 2 {
+				continue
+			}
+
+			definitions := make([]locatedError, len(is))
+			for j, i := range is {
+				path := fmt.Sprintf("%v[%v].%v", key, i, idKey)
+				paths[path] = struct{}{}
+
+				// definitions that can't be located, e.g. of an inline config, are referred to by their path
+				definitions[j] = locatedError{msg: path}
+				file, node, ok := l.positions.locate(path)
+				if ok {
+					definitions[j] = locatedError{file: file, line: node.Line, column: node.Column, msg: fmt.Sprintf("%v:%v:%v", file, node.Line, node.Column)}
+				}
+			}
+
+			for j := range definitions {
+				var others []string
+				for k := range definitions {
+					if k != j {
+						others = append(others, definitions[k].msg)
+					}
+				}
+
+				e := definitions[j]
+				e.msg = fmt.Sprintf("duplicate %v=%v, also defined at %v", idKey, id, strings.Join(others, ", "))
+				if e.line == 0 {
+					e.msg = fmt.Sprintf("%v: %v", definitions[j].msg, e.msg)
+				}
+
+				located = append(located, e)
+			}
+		}
+	}
+
+	return located, paths
+}
@@ -0,0 +1,268 @@
+package bootstrap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadShellpaneConfig(t *testing.T) {
+	files := map[string]string{
+		"/etc/shellpane/base.yaml": `
+include:
+  - teams/*.yaml
+categories:
+  - slug: ops
+    name: ops
+    color: "#ffffff"
+`,
+		"/etc/shellpane/teams/backend.yaml": `
+commands:
+  - slug: backend-logs
+    command: echo logs
+---
+views:
+  - slug: backend-logs
+    name: Backend logs
+    command: backend-logs
+    category: ops
+`,
+		"/etc/shellpane/teams/frontend.yml": `
+include:
+  - ../base.yaml
+commands:
+  - slug: frontend-build
+    command: echo build
+`,
+		"/etc/shellpane/other/README.md": `not yaml`,
+	}
+
+	newFS := func(t *testing.T, files map[string]string) afero.Fs {
+		fs := afero.NewMemMapFs()
+		for path, content := range files {
+			err := afero.WriteFile(fs, path, []byte(content), 0644)
+			require.NoError(t, err)
+		}
+
+		return fs
+	}
+
+	t.Run("file with includes", func(t *testing.T) {
+		config, loaded, err := readShellpaneConfig(newFS(t, files), "/etc/shellpane/base.yaml")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"/etc/shellpane/base.yaml", "/etc/shellpane/teams/backend.yaml"}, loaded)
+		assert.Len(t, config.Categories, 1)
+		assert.Len(t, config.Commands, 1)
+		assert.Len(t, config.Views, 1)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		config, loaded, err := readShellpaneConfig(newFS(t, files), "/etc/shellpane")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"/etc/shellpane/base.yaml", "/etc/shellpane/teams/backend.yaml", "/etc/shellpane/teams/frontend.yml"}, loaded)
+		assert.Len(t, config.Commands, 2)
+
+		err = ValidateShellpaneConfig(config)
+		require.NoError(t, err)
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		config, loaded, err := readShellpaneConfig(newFS(t, files), "/etc/shellpane/teams/*")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"/etc/shellpane/teams/backend.yaml", "/etc/shellpane/teams/frontend.yml", "/etc/shellpane/base.yaml"}, loaded)
+		assert.Len(t, config.Categories, 1)
+		assert.Len(t, config.Commands, 2)
+	})
+
+	t.Run("duplicate slugs", func(t *testing.T) {
+		fs := newFS(t, map[string]string{
+			"/etc/shellpane/a.yaml": `
+commands:
+  - slug: logs
+    command: echo a
+`,
+			"/etc/shellpane/b.yaml": `
+commands:
+  - slug: logs
+    command: echo b
+    inputs:
+      - input: A
+`,
+		})
+
+		_, err := LoadShellpaneConfig(fs, "/etc/shellpane")
+		require.Error(t, err)
+
+		assert.Equal(t, []string{
+			"/etc/shellpane/a.yaml:3:11: duplicate slug=logs, also defined at /etc/shellpane/b.yaml:3:11",
+			"/etc/shellpane/b.yaml:3:11: duplicate slug=logs, also defined at /etc/shellpane/a.yaml:3:11",
+			"/etc/shellpane/b.yaml:6:16: failed to validate command slug=logs: undefined input slug=A",
+		}, strings.Split(err.Error(), "\n"))
+	})
+
+	t.Run("validation errors with positions", func(t *testing.T) {
+		fs := newFS(t, map[string]string{
+			"/etc/shellpane/a.yaml": `
+categories:
+  - slug: ops
+    name: ops
+    color: "#ffffff"
+views:
+  - slug: logs
+    name: Logs
+    command: undefined
+    category: undefined
+`,
+			"/etc/shellpane/b.yaml": `
+commands:
+  - slug: logs
+    command: echo $A
+    inputs:
+      - input: A
+`,
+		})
+
+		_, err := LoadShellpaneConfig(fs, "/etc/shellpane")
+		require.Error(t, err)
+
+		assert.Equal(t, []string{
+			"/etc/shellpane/a.yaml:9:14: failed to validate view name=Logs: undefined command=undefined",
+			"/etc/shellpane/a.yaml:10:15: failed to validate view name=Logs: undefined category=undefined",
+			"/etc/shellpane/b.yaml:6:16: failed to validate command slug=logs: undefined input slug=A",
+		}, strings.Split(err.Error(), "\n"))
+	})
+	t.Run("unknown keys", func(t *testing.T) {
+		fs := newFS(t, map[string]string{
+			"/etc/shellpane/a.yaml": `
+categories:
+  - slug: ops
+    name: ops
+    color: "#ffffff"
+commands:
+  - slug: logs
+    command: echo logs
+views:
+  - slug: logs
+    name: Logs
+    sequnce: logs
+    category: ops
+vews: []
+`,
+		})
+
+		_, err := LoadShellpaneConfig(fs, "/etc/shellpane/a.yaml")
+		require.Error(t, err)
+
+		assert.Equal(t, []string{
+			"/etc/shellpane/a.yaml:10:5: failed to validate view name=Logs: no command and no process set",
+			"/etc/shellpane/a.yaml:12:5: unknown key=sequnce in views[0], did you mean sequence?",
+			"/etc/shellpane/a.yaml:14:1: unknown key=vews, did you mean views?",
+		}, strings.Split(err.Error(), "\n"))
+	})
+}
+
+func Test_InterpolateShellpaneConfig(t *testing.T) {
+	t.Setenv("SHELLPANE_TEST_COLOR", "#ff0000")
+	t.Setenv("SHELLPANE_TEST_RETRIES", "3")
+	t.Setenv("SHELLPANE_TEST_EMPTY", "")
+
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "/etc/shellpane/host", []byte("db.internal\n"), 0644)
+	require.NoError(t, err)
+
+	t.Run("resolved", func(t *testing.T) {
+		config, err := UnmarshalShellpaneConfig(fs, []byte(`
+categories:
+  - slug: ops
+    name: ${SHELLPANE_TEST_NAME:-Ops} on ${file:/etc/shellpane/host}
+    color: "${SHELLPANE_TEST_COLOR}"
+commands:
+  - slug: ping
+    command: ping ${HOST} $${SHELLPANE_TEST_COLOR}
+    description: $${SHELLPANE_TEST_COLOR} ${SHELLPANE_TEST_EMPTY:-empty}
+sequences:
+  - slug: ping
+    steps:
+      - name: ping
+        command: ping
+        retries: ${SHELLPANE_TEST_RETRIES}
+`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Ops on db.internal", config.Categories[0].Name)
+		assert.Equal(t, "#ff0000", config.Categories[0].Color)
+		assert.Equal(t, "ping ${HOST} $${SHELLPANE_TEST_COLOR}", config.Commands[0].Command)
+		assert.Equal(t, "${SHELLPANE_TEST_COLOR} empty", config.Commands[0].Description)
+		assert.Equal(t, 3, config.Sequences[0].Steps[0].Retries)
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		_, err := UnmarshalShellpaneConfig(fs, []byte(`
+categories:
+  - slug: ops
+    name: ${SHELLPANE_TEST_UNSET}
+    color: ${file:/etc/shellpane/missing}
+`))
+		require.Error(t, err)
+
+		lines := strings.Split(err.Error(), "\n")
+		require.Len(t, lines, 2)
+		assert.Equal(t, "4:11: unresolved variable=SHELLPANE_TEST_UNSET, set the env var or use ${SHELLPANE_TEST_UNSET:-default}", lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], "5:12: failed to open interpolated file=/etc/shellpane/missing"))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		err := afero.WriteFile(fs, "/etc/shellpane/a.yaml", []byte(`
+categories:
+  - slug: ops
+    name: ops
+    color: ${SHELLPANE_TEST_UNSET}
+`), 0644)
+		require.NoError(t, err)
+
+		_, err = LoadShellpaneConfig(fs, "/etc/shellpane/a.yaml")
+		require.Error(t, err)
+
+		assert.Contains(t, err.Error(), "/etc/shellpane/a.yaml:5:12: unresolved variable=SHELLPANE_TEST_UNSET")
+	})
+}
+
+func Test_UnmarshalShellpaneConfig(t *testing.T) {
+	t.Run("known keys", func(t *testing.T) {
+		config, err := UnmarshalShellpaneConfig(afero.NewMemMapFs(), []byte(`
+sequences:
+  - slug: a
+    onFailure:
+      - name: a
+        command: a
+        continueOnError: true
+`))
+		require.NoError(t, err)
+
+		assert.True(t, config.Sequences[0].OnFailure[0].ContinueOnError)
+	})
+
+	t.Run("unknown keys", func(t *testing.T) {
+		_, err := UnmarshalShellpaneConfig(afero.NewMemMapFs(), []byte(`
+commands:
+  - slug: a
+    command: a
+    inputs:
+      - input: A
+        required: true
+    requiresaproval: {}
+`))
+		require.Error(t, err)
+
+		assert.Equal(t, []string{
+			"7:9: unknown key=required in commands[0].inputs[0]",
+			"8:5: unknown key=requiresaproval in commands[0], did you mean requiresApproval?",
+		}, strings.Split(err.Error(), "\n"))
+	})
+}
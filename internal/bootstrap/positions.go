@@ -0,0 +1,180 @@
+package bootstrap
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// configNode is the yaml node an entity of the config was decoded from and the file it was read from
+type configNode struct {
+	file string
+	node *yaml.Node
+}
+
+// configPositions maps the entities of a merged config to the yaml nodes they were decoded from,
+// by the yaml key of their kind, e.g. views, and their index in the merged config
+type configPositions map[string][]configNode
+
+// add appends the nodes of the entities of a document for the counted entities of each kind,
+// entities without a document, e.g. of an inline config, get no node
+func (p configPositions) add(source string, doc *yaml.Node, counts map[string]int) {
+	for key, n := range counts {
+		items := mappingValue(doc, key)
+
+		for i := 0; i < n; i++ {
+			c := configNode{file: source}
+			if items != nil && items.Kind == yaml.SequenceNode && i < len(items.Content) {
+				c.node = items.Content[i]
+			}
+
+			p[key] = append(p[key], c)
+		}
+	}
+}
+
+var pathSegmentRegexp = regexp.MustCompile(`([A-Za-z]+)|\[(\d+)\]`)
+
+// locate returns the file and the node of the entity or field at path, e.g. views[2].category,
+// if a field of the path isn't set in the file, the node of the closest parent is returned
+func (p configPositions) locate(path string) (string, *yaml.Node, bool) {
+	segments := pathSegmentRegexp.FindAllStringSubmatch(path, -1)
+	if len(segments) < 2 || segments[0][1] == "" || segments[1][2] == "" {
+		return "", nil, false
+	}
+
+	i, err := strconv.Atoi(segments[1][2])
+	if err != nil || i >= len(p[segments[0][1]]) {
+		return "", nil, false
+	}
+
+	c := p[segments[0][1]][i]
+	if c.node == nil {
+		return "", nil, false
+	}
+
+	node := c.node
+	for _, s := range segments[2:] {
+		var next *yaml.Node
+		switch {
+		case s[1] != "":
+			next = mappingValue(node, s[1])
+		default:
+			j, _ := strconv.Atoi(s[2])
+			if resolveAlias(node).Kind == yaml.SequenceNode && j < len(resolveAlias(node).Content) {
+				next = resolveAlias(node).Content[j]
+			}
+		}
+
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return c.file, node, true
+}
+
+// locatedError is an error at a line and column of a file, errors that can't be located,
+// e.g. of an inline config, have no line
+type locatedError struct {
+	file   string
+	line   int
+	column int
+	msg    string
+}
+
+func (e locatedError) String() string {
+	switch {
+	case e.line == 0:
+		return e.msg
+	case e.file == "":
+		return fmt.Sprintf("%v:%v: %v", e.line, e.column, e.msg)
+	default:
+		return fmt.Sprintf("%v:%v:%v: %v", e.file, e.line, e.column, e.msg)
+	}
+}
+
+// locateErrors locates validation errors at the nodes of their paths,
+// errors that can't be located keep their path in the message
+func (p configPositions) locateErrors(errs ValidationErrors) []locatedError {
+	located := make([]locatedError, len(errs))
+	for i := range errs {
+		file, node, ok := p.locate(errs[i].Path)
+		if !ok {
+			located[i] = locatedError{msg: errs[i].Error()}
+
+			continue
+		}
+
+		located[i] = locatedError{file: file, line: node.Line, column: node.Column, msg: errs[i].Err.Error()}
+	}
+
+	return located
+}
+
+// joinLocatedErrors returns one error that lists the errors sorted by position one per line,
+// errors that can't be located go last
+func joinLocatedErrors(errs []locatedError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sorted := append([]locatedError{}, errs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		switch {
+		case (sorted[i].line == 0) != (sorted[j].line == 0):
+			return sorted[j].line == 0
+		case sorted[i].file != sorted[j].file:
+			return sorted[i].file < sorted[j].file
+		case sorted[i].line != sorted[j].line:
+			return sorted[i].line < sorted[j].line
+		default:
+			return sorted[i].column < sorted[j].column
+		}
+	})
+
+	msgs := make([]string, len(sorted))
+	for i := range sorted {
+		msgs[i] = sorted[i].String()
+	}
+
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// mappingValue returns the value of key of a mapping or document node or nil if key isn't set
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+
+	node = resolveAlias(node)
+	if node.Kind == yaml.DocumentNode && len(node.Content) != 0 {
+		node = resolveAlias(node.Content[0])
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	return node
+}
@@ -0,0 +1,243 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+)
+
+// schemaDescriptions describe the config types and their fields by type name and type name.yaml key
+var schemaDescriptions = map[string]string{
+	"ShellpaneConfig":                  "shellpane config",
+	"ShellpaneConfig.include":          "yaml files, directories or globs whose configs are merged into this config, relative paths are relative to the directory of the including file",
+	"ShellpaneConfig.users":            "users and the groups they belong to",
+	"ShellpaneConfig.groups":           "groups and the roles they are granted",
+	"ShellpaneConfig.roles":            "roles and the views and categories they grant",
+	"ShellpaneConfig.categories":       "categories that group views",
+	"ShellpaneConfig.views":            "views that run a command or a sequence",
+	"ShellpaneConfig.sequences":        "sequences of steps that run commands",
+	"ShellpaneConfig.commands":         "shell commands",
+	"ShellpaneConfig.inputs":           "inputs that are passed to commands as env vars",
+	"ShellpaneConfig.secrets":          "secrets that are injected into commands as env vars and masked in their output",
+	"ShellpaneConfig.redact":           "redact rules that apply to the output of all commands",
+	"ShellpaneConfig.tokens":           "api tokens machine clients authenticate with as bearer token",
+	"APITokenConfig.owner":             "id of the user whose permissions the token is limited to",
+	"APITokenConfig.hash":              "hex encoded sha256 hash of the token, tokens start with shellpane_, shellpane token prints a new token and its hash",
+	"APITokenConfig.expiresAt":         "time the token expires at, e.g. 2030-01-01T00:00:00Z",
+	"APITokenConfig.commands":          "slugs of the commands the token may execute directly",
+	"APITokenConfig.views":             "slugs of the views the token may execute",
+	"SecretConfig.fromEnv":             "env var of the server to load the secret from",
+	"SecretConfig.fromFile":            "file to load the secret from, a trailing newline is trimmed",
+	"UserConfig.id":                    "id of the user as sent in the user id header",
+	"UserGroupConfig.group":            "slug of a group",
+	"GroupConfig.headerGroups":         "names of the group in the groups header or the groups claim of bearer tokens, users whose groups contain the slug or one of the names are members of the group",
+	"GroupRoleConfig.role":             "slug of a role",
+	"RoleConfig.admin":                 "grants changing the config through the admin api",
+	"RoleViewConfig.view":              "slug of a view",
+	"RoleViewConfig.constraints":       "constraints on the input values the view is granted for, all have to hold",
+	"InputConstraintConfig.input":      "slug of the constrained input, it is set by the expression if expression is set",
+	"InputConstraintConfig.values":     "values the input may have, exactly one of values, regex or expression is set",
+	"InputConstraintConfig.regex":      "regex the whole value of the input has to match, exactly one of values, regex or expression is set",
+	"InputConstraintConfig.expression": "expression like namespace in [\"staging\", \"dev\"], namespace not in [...], namespace == \"dev\", namespace != \"prod\" or namespace matches \"dev-.*\", exactly one of values, regex or expression is set",
+	"RoleCategoryConfig.category":      "slug of a category, grants all views of the category",
+	"CategoryConfig.color":             "color of the category, e.g. #ffa502",
+	"CategoryConfig.parent":            "slug of the category the category is nested in, roles that are granted the parent are granted the category",
+	"CategoryConfig.order":             "sorts categories with the same parent ascending, categories of equal order keep their order in the config",
+	"ViewConfig.description":           "description of the view, rendered as markdown",
+	"ViewConfig.command":               "slug of the command the view runs, either command or sequence is set",
+	"ViewConfig.sequence":              "slug of the sequence the view runs, either command or sequence is set",
+	"ViewConfig.category":              "slug of the category of the view",
+	"ViewConfig.requiresApproval":      "requires a second user to approve a run of the view",
+	"ViewConfig.inputs":                "preset values of inputs of the command of the view",
+	"ViewConfig.order":                 "sorts views ascending, views of equal order keep their order in the config",
+	"ViewConfig.tags":                  "tags of the view",
+	"ViewInputConfig.input":            "slug of an input of the command of the view",
+	"ViewInputConfig.value":            "preset value of the input",
+	"ViewInputConfig.locked":           "users of the view can't change the value of the input",
+	"SequenceConfig.steps":             "steps that run in order, unless steps declare needs",
+	"SequenceConfig.onFailure":         "steps that run if a step failed",
+	"SequenceConfig.finally":           "steps that always run last",
+	"StepConfig.command":               "slug of the command the step runs, either command or sequence is set",
+	"StepConfig.sequence":              "slug of the sequence the step includes, either command or sequence is set",
+	"StepConfig.needs":                 "slugs of the steps that have to finish before the step starts",
+	"StepConfig.foreach":               "runs the step once for each value",
+	"StepConfig.retries":               "how often the step is retried if it fails",
+	"StepConfig.backoff":               "how long to wait before a retry",
+	"StepConfig.continueOnError":       "continues the sequence if the step fails",
+	"StepConfig.when":                  "conditions on the exit codes of previous steps that all have to hold for the step to run",
+	"StepConfig.inputs":                "inputs of the step that are set from outputs of previous steps or other inputs",
+	"StepConfig.outputs":               "outputs that are extracted from the stdout of the step",
+	"StepForeachConfig.input":          "slug of an input with one value per line, either input or values is set",
+	"StepForeachConfig.values":         "values to run the step for, either input or values is set",
+	"StepForeachConfig.as":             "name of the env var that holds the value",
+	"StepForeachConfig.parallel":       "runs the step for all values at once",
+	"StepForeachConfig.maxParallel":    "how many values run at once if parallel is set, it defaults to 8",
+	"StepConditionConfig.step":         "slug of a previous step",
+	"StepConditionConfig.exitCodes":    "exit codes of the step for which the condition holds",
+	"StepInputConfig.input":            "slug of the input that is set",
+	"StepInputConfig.output":           "output of a previous step as step.output, either output or fromInput is set",
+	"StepInputConfig.fromInput":        "slug of the input whose value is used, either output or fromInput is set",
+	"StepOutputConfig.name":            "name of the output, it is passed to later steps as env var",
+	"StepOutputConfig.regex":           "regex whose first group, or whole match, is the output",
+	"StepOutputConfig.jsonPath":        "json path into the stdout of the step",
+	"CommandConfig.command":            "shell command, inputs and secrets are passed as env vars",
+	"CommandConfig.description":        "description of the command",
+	"CommandConfig.inputs":             "inputs of the command",
+	"CommandConfig.secrets":            "secrets that are injected into the command",
+	"CommandConfig.redact":             "redact rules that apply to the output of the command",
+	"CommandConfig.requiresApproval":   "requires a second user to approve a run of the command",
+	"RedactConfig.regex":               "regex whose matches are redacted, either regex or detector is set",
+	"RedactConfig.detector":            "built-in detector of a common token format, either regex or detector is set",
+	"RedactConfig.replacement":         "replacement of matches that may reference groups of the regex, it defaults to ***",
+	"CommandSecretConfig.secret":       "slug of a secret",
+	"CommandSecretConfig.env":          "name of the env var the secret is injected as, it defaults to the secret slug",
+	"ApprovalConfig.roles":             "roles whose users may approve a run",
+	"ApprovalConfig.expiry":            "how long a run waits for approval, it defaults to 1h",
+	"ApprovalRoleConfig.role":          "slug of a role",
+	"CommandInputConfig.input":         "slug of an input",
+	"InputConfig.slug":                 "slug of the input, it is the name of the env var the value is passed as",
+	"InputConfig.type":                 "type of the input, it defaults to string",
+	"InputConfig.default":              "default value of the input, inputs of type secret have no default",
+	"InputConfig.label":                "label of the input field",
+	"InputConfig.description":          "description of the input",
+	"InputConfig.placeholder":          "placeholder of the input field",
+	"InputConfig.values":               "values an input of type enum accepts",
+	"InputConfig.optionsFrom":          "slug of the command that lists the values the input accepts, as json array or one per line",
+	"InputConfig.optionsTTL":           "how long listed options are cached, options are not cached if it is not set",
+}
+
+// schemaEnums are the values fields accept by type name.yaml key
+var schemaEnums = map[string][]string{
+	"InputConfig.type": {
+		domain.InputTypeString,
+		domain.InputTypeInt,
+		domain.InputTypeBool,
+		domain.InputTypeEnum,
+		domain.InputTypeMultiline,
+		domain.InputTypeDate,
+		domain.InputTypeSecret,
+	},
+	"RedactConfig.detector": redactDetectorNames(),
+}
+
+func redactDetectorNames() []string {
+	names := make([]string, 0, len(redactDetectors))
+	for name := range redactDetectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var timeType = reflect.TypeOf(time.Time{})
+
+// ShellpaneConfigSchema returns a json schema of the shellpane config, e.g. for editors to validate and autocomplete configs
+func ShellpaneConfigSchema() ([]byte, error) {
+	definitions := map[string]interface{}{}
+	typeSchema(reflect.TypeOf(ShellpaneConfig{}), definitions)
+
+	// the root is the definition of the config itself, as keywords next to a $ref are ignored
+	schema := definitions["ShellpaneConfig"].(map[string]interface{})
+	delete(definitions, "ShellpaneConfig")
+
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "shellpane"
+	schema["definitions"] = definitions
+
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to json marshal schema")
+	}
+
+	return append(b, '\n'), nil
+}
+
+// typeSchema returns the schema of a config type, structs are added to definitions and referenced
+func typeSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]interface{}{
+			"type":    "string",
+			"pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+		}
+	case t == timeType:
+		return map[string]interface{}{
+			"type":   "string",
+			"format": "date-time",
+		}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem(), definitions),
+		}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem(), definitions),
+		}
+	case t.Kind() == reflect.Struct:
+		ref := map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+
+		_, defined := definitions[t.Name()]
+		if defined {
+			return ref
+		}
+		// is replaced below, so that recursive types reference the definition
+		definitions[t.Name()] = nil
+
+		properties := map[string]interface{}{}
+		for key, field := range yamlFields(t) {
+			property := typeSchema(field.Type, definitions)
+
+			description, ok := schemaDescriptions[t.Name()+"."+key]
+			if ok {
+				if property["$ref"] != nil {
+					// siblings of $ref are ignored in draft-07
+					property = map[string]interface{}{"allOf": []interface{}{property}}
+				}
+				property["description"] = description
+			}
+
+			enum, ok := schemaEnums[t.Name()+"."+key]
+			if ok {
+				property["enum"] = enum
+			}
+
+			properties[key] = property
+		}
+
+		definition := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+
+		description, ok := schemaDescriptions[t.Name()]
+		if ok {
+			definition["description"] = description
+		}
+
+		definitions[t.Name()] = definition
+
+		return ref
+	default:
+		return map[string]interface{}{}
+	}
+}
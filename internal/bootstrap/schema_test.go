@@ -0,0 +1,68 @@
+package bootstrap
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ShellpaneConfigSchema(t *testing.T) {
+	t.Run("checked in schema is up to date", func(t *testing.T) {
+		schema, err := ShellpaneConfigSchema()
+		require.NoError(t, err)
+
+		b, err := ioutil.ReadFile("../../shellpane.schema.json")
+		require.NoError(t, err)
+
+		assert.Equal(t, string(schema), string(b), "run: go run ./cmd schema > shellpane.schema.json")
+	})
+
+	t.Run("descriptions and enums refer to fields", func(t *testing.T) {
+		types := map[string]reflect.Type{}
+		var collect func(t reflect.Type)
+		collect = func(t reflect.Type) {
+			for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+				t = t.Elem()
+			}
+
+			if t.Kind() != reflect.Struct || t == durationType {
+				return
+			}
+
+			_, seen := types[t.Name()]
+			if seen {
+				return
+			}
+			types[t.Name()] = t
+
+			for _, f := range yamlFields(t) {
+				collect(f.Type)
+			}
+		}
+		collect(reflect.TypeOf(ShellpaneConfig{}))
+
+		keys := []string{}
+		for key := range schemaDescriptions {
+			keys = append(keys, key)
+		}
+		for key := range schemaEnums {
+			keys = append(keys, key)
+		}
+
+		for _, key := range keys {
+			parts := strings.SplitN(key, ".", 2)
+
+			typ, ok := types[parts[0]]
+			require.True(t, ok, "undefined type of key=%v", key)
+
+			if len(parts) == 2 {
+				_, ok = yamlFields(typ)[parts[1]]
+				assert.True(t, ok, "undefined field of key=%v", key)
+			}
+		}
+	})
+}
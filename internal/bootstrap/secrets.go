@@ -0,0 +1,41 @@
+package bootstrap
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// loadSecrets returns the values of the secrets by slug, a trailing new line of secret files is removed
+func loadSecrets(fs afero.Fs, secrets []SecretConfig) (map[string]string, error) {
+	values := map[string]string{}
+	for _, s := range secrets {
+		switch {
+		case s.FromEnv != "":
+			value, ok := os.LookupEnv(s.FromEnv)
+			if !ok {
+				return nil, errors.Errorf("env=%v of secret slug=%v is not set", s.FromEnv, s.Slug)
+			}
+
+			values[s.Slug] = value
+		case s.FromFile != "":
+			f, err := fs.Open(s.FromFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to open file=%v of secret slug=%v", s.FromFile, s.Slug)
+			}
+
+			b, err := ioutil.ReadAll(f)
+			_ = f.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read file=%v of secret slug=%v", s.FromFile, s.Slug)
+			}
+
+			values[s.Slug] = strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+		}
+	}
+
+	return values, nil
+}
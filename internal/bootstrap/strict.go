@@ -0,0 +1,164 @@
+package bootstrap
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalShellpaneConfig decodes a shellpane config from yaml, interpolates env vars and files of fs
+// and fails on keys that don't exist in the config
+func UnmarshalShellpaneConfig(fs afero.Fs, b []byte) (ShellpaneConfig, error) {
+	var node yaml.Node
+	err := yaml.Unmarshal(b, &node)
+	if err != nil {
+		return ShellpaneConfig{}, errors.Wrapf(err, "failed to yaml unmarshal")
+	}
+
+	errs := append(interpolate(fs, &node, reflect.TypeOf(ShellpaneConfig{})), unknownKeys(&node, reflect.TypeOf(ShellpaneConfig{}), "")...)
+	if len(errs) != 0 {
+		return ShellpaneConfig{}, joinLocatedErrors(errs)
+	}
+
+	var config ShellpaneConfig
+	err = node.Decode(&config)
+	if err != nil {
+		return ShellpaneConfig{}, errors.Wrapf(err, "failed to decode")
+	}
+
+	return config, nil
+}
+
+// unknownKeys returns the keys of mappings of node that don't correspond to a field of t,
+// located by line and column and with a suggestion of the known key that is spelled most similar
+func unknownKeys(node *yaml.Node, t reflect.Type, path string) []locatedError {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var errs []locatedError
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for i := range node.Content {
+			errs = append(errs, unknownKeys(node.Content[i], t, path)...)
+		}
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+
+		for i := range node.Content {
+			errs = append(errs, unknownKeys(node.Content[i], t.Elem(), fmt.Sprintf("%v[%v]", path, i))...)
+		}
+	case yaml.MappingNode:
+		if t.Kind() == reflect.Map {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				errs = append(errs, unknownKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+			}
+
+			return errs
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+
+			field, ok := fields[key.Value]
+			if ok {
+				errs = append(errs, unknownKeys(node.Content[i+1], field.Type, joinPath(path, key.Value))...)
+
+				continue
+			}
+
+			msg := fmt.Sprintf("unknown key=%v", key.Value)
+			if path != "" {
+				msg += fmt.Sprintf(" in %v", path)
+			}
+
+			suggestion := suggestKey(key.Value, fields)
+			if suggestion != "" {
+				msg += fmt.Sprintf(", did you mean %v?", suggestion)
+			}
+
+			errs = append(errs, locatedError{line: key.Line, column: key.Column, msg: msg})
+		}
+	}
+
+	return errs
+}
+
+// yamlFields returns the fields of a struct by the key yaml decodes them from
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		switch key {
+		case "-":
+			continue
+		case "":
+			key = strings.ToLower(f.Name)
+		}
+
+		fields[key] = f
+	}
+
+	return fields
+}
+
+// suggestKey returns the known key that is most similar to key, or an empty string if none is similar enough
+func suggestKey(key string, fields map[string]reflect.StructField) string {
+	best := ""
+	bestDistance := len(key)/2 + 1
+	for known := range fields {
+		d := levenshtein(strings.ToLower(key), strings.ToLower(known))
+		if d < bestDistance || d == bestDistance && best != "" && known < best {
+			best = known
+			bestDistance = d
+		}
+	}
+
+	return best
+}
+
+func levenshtein(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j] + 1
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
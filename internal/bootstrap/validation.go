@@ -1,213 +1,577 @@
 package bootstrap
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/jsonpathutil"
 )
 
-func ValidateShellpaneConfig(config ShellpaneConfig) error {
-	err := validateInputs(config.Inputs)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate inputs")
+// ValidationError is an error of the config at Path, e.g. views[2].category
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%v: %v", e.Path, e.Err)
+}
+
+// ValidationErrors are all errors found while validating a config
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// orNil returns nil if there are no errors, so that callers can check err != nil
+func (e ValidationErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
 	}
 
+	return e
+}
+
+// fieldErrorf returns an error of the field at path of the validated entity
+func fieldErrorf(path string, format string, args ...interface{}) ValidationError {
+	return ValidationError{Path: path, Err: errors.Errorf(format, args...)}
+}
+
+// atPath locates the errors of err at path and wraps them with msg, errors that are located already,
+// e.g. at a field of an entity, keep their path relative to path
+func atPath(path string, msg string, err error) ValidationErrors {
+	var errs ValidationErrors
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case ValidationErrors:
+		errs = e
+	case ValidationError:
+		errs = ValidationErrors{e}
+	default:
+		errs = ValidationErrors{{Err: err}}
+	}
+
+	located := make(ValidationErrors, len(errs))
+	for i := range errs {
+		located[i] = ValidationError{Path: joinPath(path, errs[i].Path), Err: errs[i].Err}
+		if msg != "" {
+			located[i].Err = errors.Wrap(errs[i].Err, msg)
+		}
+	}
+
+	return located
+}
+
+func joinPath(path string, sub string) string {
+	switch {
+	case sub == "":
+		return path
+	case path == "", strings.HasPrefix(sub, "["):
+		return path + sub
+	default:
+		return path + "." + sub
+	}
+}
+
+func ValidateShellpaneConfig(config ShellpaneConfig) error {
+	var errs ValidationErrors
+
+	errs = append(errs, atPath("inputs", "", validateInputs(config.Inputs))...)
+
 	definedInputs := map[string]struct{}{}
 	for i := range config.Inputs {
 		definedInputs[config.Inputs[i].Slug] = struct{}{}
 	}
 
-	err = validateCommands(definedInputs, config.Commands)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate commands")
+	errs = append(errs, atPath("commands", "", validateCommands(definedInputs, config.Commands))...)
+	errs = append(errs, atPath("redact", "", validateRedacts(config.Redact))...)
+	errs = append(errs, atPath("secrets", "", validateSecrets(config.Secrets))...)
+
+	definedSecrets := map[string]struct{}{}
+	for i := range config.Secrets {
+		definedSecrets[config.Secrets[i].Slug] = struct{}{}
 	}
 
+	errs = append(errs, atPath("commands", "", validateCommandSecrets(definedSecrets, config.Commands))...)
+
 	definedCommands := map[string]struct{}{}
 	for i := range config.Commands {
 		definedCommands[config.Commands[i].Slug] = struct{}{}
 	}
 
-	err = validateSequences(definedCommands, config.Sequences)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate sequences")
-	}
+	errs = append(errs, atPath("inputs", "", validateInputOptions(config.Inputs, config.Commands))...)
+	errs = append(errs, atPath("sequences", "", validateSequences(definedCommands, config.Sequences))...)
 
 	definedSequences := map[string]struct{}{}
 	for i := range config.Sequences {
 		definedSequences[config.Sequences[i].Slug] = struct{}{}
 	}
 
-	err = validateCategories(config.Categories)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate categories")
-	}
+	errs = append(errs, atPath("categories", "", validateCategories(config.Categories))...)
 
 	definedCategories := map[string]struct{}{}
 	for i := range config.Categories {
 		definedCategories[config.Categories[i].Slug] = struct{}{}
 	}
 
-	err = validateViews(definedCommands, definedSequences, definedCategories, config.Views)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate views")
-	}
+	errs = append(errs, atPath("views", "", validateViews(definedCommands, definedSequences, definedCategories, config.Views))...)
+	errs = append(errs, atPath("views", "", validateViewInputs(config.Inputs, config.Commands, config.Views))...)
 
 	definedViews := map[string]struct{}{}
 	for i := range config.Views {
 		definedViews[config.Views[i].Slug] = struct{}{}
 	}
 
-	err = validateRoles(definedCategories, definedViews, config.Roles)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate roles")
-	}
+	errs = append(errs, atPath("roles", "", validateRoles(definedCategories, definedViews, config.Roles))...)
+	errs = append(errs, atPath("roles", "", validateRoleConstraints(config.Commands, config.Sequences, config.Views, config.Roles))...)
 
 	definedRoles := map[string]struct{}{}
 	for i := range config.Roles {
 		definedRoles[config.Roles[i].Slug] = struct{}{}
 	}
 
-	err = validateGroups(definedRoles, config.Groups)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate groups")
-	}
+	errs = append(errs, atPath("", "", validateApprovals(definedRoles, config.Commands, config.Sequences, config.Views))...)
+	errs = append(errs, atPath("groups", "", validateGroups(definedRoles, config.Groups))...)
 
 	definedGroups := map[string]struct{}{}
 	for i := range config.Groups {
 		definedGroups[config.Groups[i].Slug] = struct{}{}
 	}
 
-	err = validateUsers(definedGroups, config.Users)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate users")
+	errs = append(errs, atPath("users", "", validateUsers(definedGroups, config.Users))...)
+
+	definedUsers := map[string]struct{}{}
+	for i := range config.Users {
+		definedUsers[config.Users[i].ID] = struct{}{}
 	}
 
-	return nil
+	errs = append(errs, atPath("tokens", "", validateAPITokens(definedUsers, definedCommands, definedViews, config.Tokens))...)
+
+	return errs.orNil()
+}
+
+func validateApprovals(definedRoles map[string]struct{}, commands []CommandConfig, sequences []SequenceConfig, views []ViewConfig) error {
+	var errs ValidationErrors
+
+	approvedCommands := map[string]struct{}{}
+	for i := range commands {
+		if commands[i].RequiresApproval == nil {
+			continue
+		}
+
+		err := validateApproval(definedRoles, *commands[i].RequiresApproval)
+		errs = append(errs, atPath(fmt.Sprintf("commands[%v].requiresApproval", i), fmt.Sprintf("failed to validate approval of command slug=%v", commands[i].Slug), err)...)
+
+		approvedCommands[commands[i].Slug] = struct{}{}
+	}
+
+	// the approval of a view applies to its command wherever the command runs
+	for i := range views {
+		if views[i].RequiresApproval != nil && views[i].CommandSlug != "" {
+			approvedCommands[views[i].CommandSlug] = struct{}{}
+		}
+	}
+
+	// a sequence can't pause for an approval, so commands that require one can't be part of a sequence
+	for i := range sequences {
+		for _, group := range stepGroups(sequences[i]) {
+			for j := range group.steps {
+				_, ok := approvedCommands[group.steps[j].CommandSlug]
+				if ok {
+					errs = append(errs, fieldErrorf(fmt.Sprintf("sequences[%v].%v[%v].command", i, group.key, j), "command=%v requires approval and can't be a step of sequence=%v", group.steps[j].CommandSlug, sequences[i].Slug))
+				}
+			}
+		}
+	}
+
+	for i := range views {
+		if views[i].RequiresApproval == nil {
+			continue
+		}
+
+		if views[i].SequenceSlug != "" {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("views[%v].requiresApproval", i), "view slug=%v of a sequence can't require approval", views[i].Slug))
+
+			continue
+		}
+
+		err := validateApproval(definedRoles, *views[i].RequiresApproval)
+		errs = append(errs, atPath(fmt.Sprintf("views[%v].requiresApproval", i), fmt.Sprintf("failed to validate approval of view slug=%v", views[i].Slug), err)...)
+	}
+
+	return errs.orNil()
+}
+
+func validateApproval(definedRoles map[string]struct{}, approval ApprovalConfig) error {
+	var errs ValidationErrors
+
+	if len(approval.Roles) == 0 {
+		errs = append(errs, fieldErrorf("roles", "no approver roles defined"))
+	}
+
+	for i := range approval.Roles {
+		_, defined := definedRoles[approval.Roles[i].RoleSlug]
+		if !defined {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("roles[%v].role", i), "undefined role=%v", approval.Roles[i].RoleSlug))
+		}
+	}
+
+	if approval.Expiry < 0 {
+		errs = append(errs, fieldErrorf("expiry", "negative expiry=%v", approval.Expiry))
+	}
+
+	return errs.orNil()
 }
 
 func validateUsers(definedGroups map[string]struct{}, users []UserConfig) error {
+	var errs ValidationErrors
+
 	for i := range users {
 		err := validateUser(definedGroups, users[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate user id=%v", users[i].ID)
-		}
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate user id=%v", users[i].ID), err)...)
 	}
 
 	seenIDs := map[string]struct{}{}
 	for i := range users {
 		_, seen := seenIDs[users[i].ID]
 		if seen {
-			return errors.Errorf("duplicate id=%v", users[i].ID)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].id", i), "duplicate id=%v", users[i].ID))
 		}
 		seenIDs[users[i].ID] = struct{}{}
 	}
 
-	return nil
+	return errs.orNil()
+}
+
+func validateAPITokens(definedUsers map[string]struct{}, definedCommands map[string]struct{}, definedViews map[string]struct{}, tokens []APITokenConfig) error {
+	var errs ValidationErrors
+
+	for i := range tokens {
+		err := validateAPIToken(definedUsers, definedCommands, definedViews, tokens[i])
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate token slug=%v", tokens[i].Slug), err)...)
+	}
+
+	seenSlugs := map[string]struct{}{}
+	seenHashes := map[string]struct{}{}
+	for i := range tokens {
+		_, seen := seenSlugs[tokens[i].Slug]
+		if seen {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", tokens[i].Slug))
+		}
+		seenSlugs[tokens[i].Slug] = struct{}{}
+
+		hash := strings.ToLower(tokens[i].Hash)
+		_, seen = seenHashes[hash]
+		if seen {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].hash", i), "duplicate hash"))
+		}
+		seenHashes[hash] = struct{}{}
+	}
+
+	return errs.orNil()
+}
+
+var apiTokenHashRegexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+func validateAPIToken(definedUsers map[string]struct{}, definedCommands map[string]struct{}, definedViews map[string]struct{}, token APITokenConfig) error {
+	var errs ValidationErrors
+
+	if token.Slug == "" {
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
+	}
+
+	_, defined := definedUsers[token.Owner]
+	if !defined {
+		errs = append(errs, fieldErrorf("owner", "undefined user=%v", token.Owner))
+	}
+
+	if !apiTokenHashRegexp.MatchString(token.Hash) {
+		errs = append(errs, fieldErrorf("hash", "hash is not a hex encoded sha256 hash"))
+	}
+
+	if token.ExpiresAt.IsZero() {
+		errs = append(errs, fieldErrorf("expiresAt", "expiresAt is empty"))
+	}
+
+	if len(token.Commands) == 0 && len(token.Views) == 0 {
+		errs = append(errs, fieldErrorf("", "token has neither commands nor views"))
+	}
+
+	for i := range token.Commands {
+		_, defined := definedCommands[token.Commands[i]]
+		if !defined {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("commands[%v]", i), "undefined command=%v", token.Commands[i]))
+		}
+	}
+
+	for i := range token.Views {
+		_, defined := definedViews[token.Views[i]]
+		if !defined {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("views[%v]", i), "undefined view=%v", token.Views[i]))
+		}
+	}
+
+	return errs.orNil()
 }
 
 func validateUser(definedGroups map[string]struct{}, user UserConfig) error {
+	var errs ValidationErrors
+
 	if user.ID == "" {
-		return errors.New("id is empty")
+		errs = append(errs, fieldErrorf("id", "id is empty"))
 	}
 
 	for i := range user.Groups {
 		_, defined := definedGroups[user.Groups[i].GroupSlug]
 		if !defined {
-			return errors.Errorf("undefined group=%v", user.Groups[i].GroupSlug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("groups[%v].group", i), "undefined group=%v", user.Groups[i].GroupSlug))
 		}
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 func validateGroups(definedRoles map[string]struct{}, groups []GroupConfig) error {
+	var errs ValidationErrors
+
 	for i := range groups {
 		err := validateGroup(definedRoles, groups[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate group slug=%v", groups[i].Slug)
-		}
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate group slug=%v", groups[i].Slug), err)...)
 	}
 
 	seenSlugs := map[string]struct{}{}
 	for i := range groups {
 		_, seen := seenSlugs[groups[i].Slug]
 		if seen {
-			return errors.Errorf("duplicate slug=%v", groups[i].Slug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", groups[i].Slug))
 		}
 		seenSlugs[groups[i].Slug] = struct{}{}
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 func validateGroup(definedRoles map[string]struct{}, group GroupConfig) error {
+	var errs ValidationErrors
+
 	if group.Slug == "" {
-		return errors.New("slug is empty")
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
 	}
 
 	for i := range group.Roles {
 		_, defined := definedRoles[group.Roles[i].RoleSlug]
 		if !defined {
-			return errors.Errorf("undefined role=%v", group.Roles[i].RoleSlug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("roles[%v].role", i), "undefined role=%v", group.Roles[i].RoleSlug))
 		}
 	}
 
-	return nil
+	seenHeaderGroups := map[string]struct{}{}
+	for i := range group.HeaderGroups {
+		if group.HeaderGroups[i] == "" {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("headerGroups[%v]", i), "header group is empty"))
+		}
+
+		_, seen := seenHeaderGroups[group.HeaderGroups[i]]
+		if seen {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("headerGroups[%v]", i), "duplicate header group=%v", group.HeaderGroups[i]))
+		}
+		seenHeaderGroups[group.HeaderGroups[i]] = struct{}{}
+	}
+
+	return errs.orNil()
 }
 
 func validateRoles(definedCategories map[string]struct{}, definedViews map[string]struct{}, roles []RoleConfig) error {
+	var errs ValidationErrors
+
 	for i := range roles {
 		err := validateRole(definedCategories, definedViews, roles[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate role slug=%v", roles[i].Slug)
-		}
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate role slug=%v", roles[i].Slug), err)...)
 	}
 
 	seenSlugs := map[string]struct{}{}
 	for i := range roles {
 		_, seen := seenSlugs[roles[i].Slug]
 		if seen {
-			return errors.Errorf("duplicate slug=%v", roles[i].Slug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", roles[i].Slug))
 		}
 		seenSlugs[roles[i].Slug] = struct{}{}
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 func validateRole(definedCategories map[string]struct{}, definedViews map[string]struct{}, role RoleConfig) error {
+	var errs ValidationErrors
+
 	if role.Slug == "" {
-		return errors.New("slug is empty")
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
 	}
 
 	for i := range role.Views {
 		_, defined := definedViews[role.Views[i].ViewSlug]
 		if !defined {
-			return errors.Errorf("undefined view=%v", role.Views[i].ViewSlug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("views[%v].view", i), "undefined view=%v", role.Views[i].ViewSlug))
+		}
+
+		for j := range role.Views[i].Constraints {
+			err := validateInputConstraint(role.Views[i].Constraints[j])
+			errs = append(errs, atPath(fmt.Sprintf("views[%v].constraints[%v]", i, j), "", err)...)
 		}
 	}
 
 	for i := range role.Categories {
 		_, defined := definedCategories[role.Categories[i].CategorySlug]
 		if !defined {
-			return errors.Errorf("undefined category=%v", role.Categories[i].CategorySlug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("categories[%v].category", i), "undefined category=%v", role.Categories[i].CategorySlug))
 		}
 	}
 
-	return nil
+	return errs.orNil()
+}
+
+func validateInputConstraint(constraint InputConstraintConfig) error {
+	var errs ValidationErrors
+
+	var forms int
+	if constraint.Values != nil {
+		forms++
+	}
+	if constraint.Regex != "" {
+		forms++
+	}
+	if constraint.Expression != "" {
+		forms++
+	}
+
+	switch {
+	case forms != 1:
+		errs = append(errs, fieldErrorf("", "exactly one of values, regex or expression has to be set"))
+	case constraint.Expression != "":
+		if constraint.InputSlug != "" {
+			errs = append(errs, fieldErrorf("input", "input is set by the expression"))
+		}
+
+		_, err := generateInputConstraint(constraint)
+		if err != nil {
+			errs = append(errs, ValidationError{Path: "expression", Err: errors.Wrapf(err, "invalid expression")})
+		}
+	default:
+		if constraint.InputSlug == "" {
+			errs = append(errs, fieldErrorf("input", "input is empty"))
+		}
+
+		if constraint.Regex != "" {
+			_, err := regexp.Compile(constraint.Regex)
+			if err != nil {
+				errs = append(errs, ValidationError{Path: "regex", Err: errors.Wrapf(err, "invalid regex")})
+			}
+		}
+	}
+
+	return errs.orNil()
+}
+
+// validateRoleConstraints validates that constraints of view grants restrict inputs of the commands the view runs
+func validateRoleConstraints(commands []CommandConfig, sequences []SequenceConfig, views []ViewConfig, roles []RoleConfig) error {
+	var errs ValidationErrors
+
+	commandInputs := map[string]map[string]struct{}{}
+	for i := range commands {
+		commandInputs[commands[i].Slug] = map[string]struct{}{}
+		for j := range commands[i].Inputs {
+			commandInputs[commands[i].Slug][commands[i].Inputs[j].InputSlug] = struct{}{}
+		}
+	}
+
+	sequencesM := map[string]SequenceConfig{}
+	for i := range sequences {
+		sequencesM[sequences[i].Slug] = sequences[i]
+	}
+
+	viewInputs := map[string]map[string]struct{}{}
+	for i := range views {
+		inputs := map[string]struct{}{}
+		collectInputs(commandInputs, sequencesM, views[i].CommandSlug, views[i].SequenceSlug, map[string]struct{}{}, inputs)
+		viewInputs[views[i].Slug] = inputs
+	}
+
+	for i := range roles {
+		for j := range roles[i].Views {
+			inputs, ok := viewInputs[roles[i].Views[j].ViewSlug]
+			if !ok {
+				continue
+			}
+
+			for k, constraint := range roles[i].Views[j].Constraints {
+				c, err := generateInputConstraint(constraint)
+				if err != nil || c.InputSlug == "" {
+					continue
+				}
+
+				_, ok := inputs[c.InputSlug]
+				if !ok {
+					errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].views[%v].constraints[%v]", i, j, k), "input=%v is not an input of view=%v", c.InputSlug, roles[i].Views[j].ViewSlug))
+				}
+			}
+		}
+	}
+
+	return errs.orNil()
+}
+
+// collectInputs adds the inputs of commandSlug, or of the commands of sequenceSlug and its nested sequences, to inputs
+func collectInputs(commandInputs map[string]map[string]struct{}, sequences map[string]SequenceConfig, commandSlug string, sequenceSlug string, seen map[string]struct{}, inputs map[string]struct{}) {
+	for input := range commandInputs[commandSlug] {
+		inputs[input] = struct{}{}
+	}
+
+	if sequenceSlug == "" {
+		return
+	}
+
+	_, ok := seen[sequenceSlug]
+	if ok {
+		return
+	}
+	seen[sequenceSlug] = struct{}{}
+
+	for _, group := range stepGroups(sequences[sequenceSlug]) {
+		for _, step := range group.steps {
+			collectInputs(commandInputs, sequences, step.CommandSlug, step.SequenceSlug, seen, inputs)
+		}
+	}
 }
 
 func validateViews(definedCommands map[string]struct{}, definedSequences map[string]struct{}, definedCategories map[string]struct{}, views []ViewConfig) error {
+	var errs ValidationErrors
+
 	for i := range views {
 		err := validateView(definedCommands, definedSequences, definedCategories, views[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate view name=%v", views[i].Name)
-		}
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate view name=%v", views[i].Name), err)...)
 	}
 
 	seenNames := map[string]struct{}{}
 	for i := range views {
 		_, seen := seenNames[views[i].Name]
 		if seen {
-			return errors.Errorf("duplicate name=%v", views[i].Name)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].name", i), "duplicate name=%v", views[i].Name))
 		}
 		seenNames[views[i].Name] = struct{}{}
 	}
@@ -216,217 +580,891 @@ func validateViews(definedCommands map[string]struct{}, definedSequences map[str
 	for i := range views {
 		_, seen := seenSlugs[views[i].Slug]
 		if seen {
-			return errors.Errorf("duplicate slug=%v", views[i].Slug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", views[i].Slug))
 		}
 		seenSlugs[views[i].Slug] = struct{}{}
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 func validateView(definedCommands map[string]struct{}, definedSequences map[string]struct{}, definedCategories map[string]struct{}, view ViewConfig) error {
+	var errs ValidationErrors
+
 	if view.Name == "" {
-		return errors.New("name is empty")
+		errs = append(errs, fieldErrorf("name", "name is empty"))
 	}
 
 	if view.Slug == "" {
-		return errors.New("slug is empty")
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
 	}
 
 	switch {
 	case view.CommandSlug != "" && view.SequenceSlug != "":
-		return errors.New("command and process set")
+		errs = append(errs, fieldErrorf("", "command and process set"))
 	case view.CommandSlug == "" && view.SequenceSlug == "":
-		return errors.New("no command and no process set")
+		errs = append(errs, fieldErrorf("", "no command and no process set"))
 	case view.CommandSlug != "":
 		_, defined := definedCommands[view.CommandSlug]
 		if !defined {
-			return errors.Errorf("undefined command=%v", view.CommandSlug)
+			errs = append(errs, fieldErrorf("command", "undefined command=%v", view.CommandSlug))
 		}
 	case view.SequenceSlug != "":
 		_, defined := definedSequences[view.SequenceSlug]
 		if !defined {
-			return errors.Errorf("undefined process=%v", view.SequenceSlug)
+			errs = append(errs, fieldErrorf("sequence", "undefined process=%v", view.SequenceSlug))
 		}
 	}
 
 	_, defined := definedCategories[view.CategorySlug]
 	if !defined {
-		return errors.Errorf("undefined category=%v", view.CategorySlug)
+		errs = append(errs, fieldErrorf("category", "undefined category=%v", view.CategorySlug))
 	}
 
-	return nil
+	seenTags := map[string]struct{}{}
+	for i := range view.Tags {
+		if view.Tags[i] == "" {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("tags[%v]", i), "tag is empty"))
+		}
+
+		_, seen := seenTags[view.Tags[i]]
+		if seen {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("tags[%v]", i), "duplicate tag=%v", view.Tags[i]))
+		}
+		seenTags[view.Tags[i]] = struct{}{}
+	}
+
+	return errs.orNil()
+}
+
+// validateViewInputs validates that views only preset valid values of inputs of their command
+func validateViewInputs(inputs []InputConfig, commands []CommandConfig, views []ViewConfig) error {
+	var errs ValidationErrors
+
+	inputsM := map[string]InputConfig{}
+	for i := range inputs {
+		inputsM[inputs[i].Slug] = inputs[i]
+	}
+
+	commandInputs := map[string]map[string]struct{}{}
+	for i := range commands {
+		commandInputs[commands[i].Slug] = map[string]struct{}{}
+		for j := range commands[i].Inputs {
+			commandInputs[commands[i].Slug][commands[i].Inputs[j].InputSlug] = struct{}{}
+		}
+	}
+
+	for i := range views {
+		if len(views[i].Inputs) == 0 {
+			continue
+		}
+
+		if views[i].CommandSlug == "" {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].inputs", i), "view slug=%v presets inputs, but doesn't run a command", views[i].Slug))
+
+			continue
+		}
+
+		seenInputs := map[string]struct{}{}
+		for j, vi := range views[i].Inputs {
+			path := fmt.Sprintf("[%v].inputs[%v]", i, j)
+
+			_, ok := commandInputs[views[i].CommandSlug][vi.InputSlug]
+			if !ok {
+				errs = append(errs, fieldErrorf(path+".input", "input=%v of view slug=%v is not an input of command=%v", vi.InputSlug, views[i].Slug, views[i].CommandSlug))
+
+				continue
+			}
+
+			_, seen := seenInputs[vi.InputSlug]
+			if seen {
+				errs = append(errs, fieldErrorf(path+".input", "duplicate input=%v of view slug=%v", vi.InputSlug, views[i].Slug))
+			}
+			seenInputs[vi.InputSlug] = struct{}{}
+
+			input := inputsM[vi.InputSlug]
+
+			// presets are served to the browser with the view configs
+			if input.Type == domain.InputTypeSecret {
+				errs = append(errs, fieldErrorf(path+".input", "input=%v of view slug=%v is a secret and can't be preset", vi.InputSlug, views[i].Slug))
+
+				continue
+			}
+
+			if vi.Value == "" && !vi.Locked {
+				continue
+			}
+			_, err := domain.InputConfig{Type: input.Type, Values: input.Values, OptionsCommandSlug: input.OptionsFrom}.Convert(vi.Value)
+			if err != nil {
+				errs = append(errs, ValidationError{Path: path + ".value", Err: errors.Wrapf(err, "invalid value of input=%v of view slug=%v", vi.InputSlug, views[i].Slug)})
+			}
+		}
+	}
+
+	return errs.orNil()
 }
 
 func validateCategories(categories []CategoryConfig) error {
+	var errs ValidationErrors
+
 	for i := range categories {
 		err := validateCategory(categories[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate process slug=%v", categories[i].Slug)
-		}
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate category slug=%v", categories[i].Slug), err)...)
 	}
 
 	seenSlugs := map[string]struct{}{}
 	for i := range categories {
 		_, seen := seenSlugs[categories[i].Slug]
 		if seen {
-			return errors.Errorf("duplicate slug=%v", categories[i].Slug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", categories[i].Slug))
 		}
 		seenSlugs[categories[i].Slug] = struct{}{}
 	}
 
-	return nil
+	parents := map[string]string{}
+	for i := range categories {
+		parents[categories[i].Slug] = categories[i].Parent
+	}
+
+	for i := range categories {
+		if categories[i].Parent == "" {
+			continue
+		}
+
+		_, defined := parents[categories[i].Parent]
+		if !defined {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].parent", i), "undefined parent=%v", categories[i].Parent))
+
+			continue
+		}
+
+		// a category is part of a cycle if following its parents leads back to it
+		visited := map[string]struct{}{}
+		for parent := categories[i].Parent; parent != ""; parent = parents[parent] {
+			if parent == categories[i].Slug {
+				errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].parent", i), "category=%v is nested in itself", categories[i].Slug))
+
+				break
+			}
+
+			_, seen := visited[parent]
+			if seen {
+				break
+			}
+			visited[parent] = struct{}{}
+		}
+	}
+
+	return errs.orNil()
 }
 
 func validateCategory(category CategoryConfig) error {
+	var errs ValidationErrors
+
 	if category.Slug == "" {
-		return errors.New("slug is empty")
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
 	}
 
 	if category.Name == "" {
-		return errors.New("name is empty")
+		errs = append(errs, fieldErrorf("name", "name is empty"))
 	}
 
 	if category.Color == "" {
-		return errors.New("color is empty")
+		errs = append(errs, fieldErrorf("color", "color is empty"))
 	}
 
-	return nil
+	return errs.orNil()
+}
+
+// stepGroup is a list of steps of a sequence together with its yaml key
+type stepGroup struct {
+	key   string
+	steps []StepConfig
+}
+
+func stepGroups(sequence SequenceConfig) []stepGroup {
+	return []stepGroup{
+		{key: "steps", steps: sequence.Steps},
+		{key: "onFailure", steps: sequence.OnFailure},
+		{key: "finally", steps: sequence.Finally},
+	}
 }
 
 func validateSequences(definedCommands map[string]struct{}, processes []SequenceConfig) error {
+	var errs ValidationErrors
+
+	definedSequences := map[string]struct{}{}
 	for i := range processes {
-		err := validateSequence(definedCommands, processes[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate process slug=%v", processes[i].Slug)
-		}
+		definedSequences[processes[i].Slug] = struct{}{}
 	}
 
-	seenSlugs := map[string]struct{}{}
 	for i := range processes {
-		_, seen := seenSlugs[processes[i].Slug]
+		err := validateSequence(definedCommands, definedSequences, processes[i])
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate process slug=%v", processes[i].Slug), err)...)
+	}
+
+	indexes := map[string]int{}
+	for i := range processes {
+		_, seen := indexes[processes[i].Slug]
 		if seen {
-			return errors.Errorf("duplicate slug=%v", processes[i].Slug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", processes[i].Slug))
+
+			continue
 		}
-		seenSlugs[processes[i].Slug] = struct{}{}
+		indexes[processes[i].Slug] = i
 	}
 
-	return nil
+	included := map[string][]string{}
+	for i := range processes {
+		for _, group := range stepGroups(processes[i]) {
+			for ii := range group.steps {
+				if group.steps[ii].SequenceSlug != "" {
+					included[processes[i].Slug] = append(included[processes[i].Slug], group.steps[ii].SequenceSlug)
+				}
+			}
+		}
+	}
+
+	cycle := findCycle(included)
+	if cycle != nil {
+		errs = append(errs, fieldErrorf(fmt.Sprintf("[%v]", indexes[cycle[0]]), "recursive sequence inclusion=%v", strings.Join(cycle, " -> ")))
+	}
+
+	return errs.orNil()
 }
 
-func validateSequence(definedCommands map[string]struct{}, process SequenceConfig) error {
+func validateSequence(definedCommands map[string]struct{}, definedSequences map[string]struct{}, process SequenceConfig) error {
+	var errs ValidationErrors
+
 	if process.Slug == "" {
-		return errors.New("slug is empty")
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
 	}
 
 	if len(process.Steps) == 0 {
-		return errors.New("no steps defined")
+		errs = append(errs, fieldErrorf("steps", "no steps defined"))
 	}
 
-	err := validateSteps(definedCommands, process.Steps)
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate steps")
+	seenSlugs := map[string]struct{}{}
+	for _, group := range stepGroups(process) {
+		for i := range group.steps {
+			if group.steps[i].Slug == "" {
+				continue
+			}
+
+			_, seen := seenSlugs[group.steps[i].Slug]
+			if seen {
+				errs = append(errs, fieldErrorf(fmt.Sprintf("%v[%v].slug", group.key, i), "duplicate step slug=%v", group.steps[i].Slug))
+			}
+			seenSlugs[group.steps[i].Slug] = struct{}{}
+		}
 	}
 
-	return nil
+	err := validateStepNeeds(process.Steps)
+	errs = append(errs, atPath("steps", "failed to validate step needs", err)...)
+
+	outputs := map[string]map[string]struct{}{}
+	for i := range process.Steps {
+		if process.Steps[i].Slug != "" {
+			outputs[process.Steps[i].Slug] = stepOutputNames(process.Steps[i])
+		}
+	}
+
+	upstream := upstreamSteps(process.Steps)
+	for i := range process.Steps {
+		upstreamOutputs := map[string]map[string]struct{}{}
+		for slug := range upstream[i] {
+			upstreamOutputs[slug] = outputs[slug]
+		}
+
+		err := validateStep(definedCommands, definedSequences, upstreamOutputs, process.Steps[i])
+		errs = append(errs, atPath(fmt.Sprintf("steps[%v]", i), fmt.Sprintf("failed to validate step name=%v", process.Steps[i].Name), err)...)
+	}
+
+	for _, group := range stepGroups(process)[1:] {
+		for i := range group.steps {
+			if len(group.steps[i].Needs) != 0 {
+				errs = append(errs, fieldErrorf(fmt.Sprintf("%v[%v].needs", group.key, i), "cleanup step name=%v declares needs", group.steps[i].Name))
+			}
+		}
+	}
+
+	err = validateSteps(definedCommands, definedSequences, outputs, process.OnFailure)
+	errs = append(errs, atPath("onFailure", "failed to validate onFailure steps", err)...)
+
+	err = validateSteps(definedCommands, definedSequences, outputs, process.Finally)
+	errs = append(errs, atPath("finally", "failed to validate finally steps", err)...)
+
+	return errs.orNil()
 }
 
-func validateSteps(definedCommands map[string]struct{}, steps []StepConfig) error {
+// validateStepNeeds validates that steps only need defined steps and that needs don't form a cycle
+func validateStepNeeds(steps []StepConfig) error {
+	var errs ValidationErrors
+
+	needs := map[string][]string{}
 	for i := range steps {
-		err := validateStep(definedCommands, steps[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate step name=%v", steps[i].Name)
+		seenNeeds := map[string]struct{}{}
+		for j, need := range steps[i].Needs {
+			_, seen := seenNeeds[need]
+			if seen {
+				errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].needs[%v]", i, j), "step name=%v has duplicate need=%v", steps[i].Name, need))
+			}
+			seenNeeds[need] = struct{}{}
+		}
+
+		if steps[i].Slug != "" {
+			needs[steps[i].Slug] = steps[i].Needs
+		}
+	}
+
+	for i := range steps {
+		for j, need := range steps[i].Needs {
+			_, defined := needs[need]
+			if !defined {
+				errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].needs[%v]", i, j), "step name=%v needs undefined step=%v", steps[i].Name, need))
+			}
+		}
+	}
+
+	cycle := findCycle(needs)
+	if cycle != nil {
+		errs = append(errs, fieldErrorf("", "needs form a cycle=%v", strings.Join(cycle, " -> ")))
+	}
+
+	return errs.orNil()
+}
+
+// findCycle returns the first cycle it finds in the graph of edges, or nil if there is none
+func findCycle(edges map[string][]string) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	nodes := make([]string, 0, len(edges))
+	for node := range edges {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	state := map[string]int{}
+	var visit func(node string, path []string) []string
+	visit = func(node string, path []string) []string {
+		switch state[node] {
+		case visiting:
+			return append(path, node)
+		case visited:
+			return nil
+		}
+
+		state[node] = visiting
+		for _, next := range edges[node] {
+			cycle := visit(next, append(path, node))
+			if cycle != nil {
+				return cycle
+			}
+		}
+		state[node] = visited
+
+		return nil
+	}
+
+	for _, node := range nodes {
+		cycle := visit(node, nil)
+		if cycle != nil {
+			return cycle
 		}
 	}
 
 	return nil
 }
 
-func validateStep(definedCommands map[string]struct{}, step StepConfig) error {
+// upstreamSteps returns for each step the slugs of the steps that are guaranteed to finish before it starts.
+// Steps run in order, unless any step declares needs, then each step only waits for its transitive needs.
+func upstreamSteps(steps []StepConfig) []map[string]struct{} {
+	isGraph := false
+	needs := map[string][]string{}
+	for i := range steps {
+		if len(steps[i].Needs) != 0 {
+			isGraph = true
+		}
+
+		needs[steps[i].Slug] = steps[i].Needs
+	}
+
+	upstream := make([]map[string]struct{}, len(steps))
+	for i := range steps {
+		upstream[i] = map[string]struct{}{}
+
+		if !isGraph {
+			for ii := 0; ii < i; ii++ {
+				if steps[ii].Slug != "" {
+					upstream[i][steps[ii].Slug] = struct{}{}
+				}
+			}
+
+			continue
+		}
+
+		pending := append([]string{}, steps[i].Needs...)
+		for len(pending) != 0 {
+			slug := pending[0]
+			pending = pending[1:]
+
+			_, seen := upstream[i][slug]
+			if seen {
+				continue
+			}
+
+			upstream[i][slug] = struct{}{}
+			pending = append(pending, needs[slug]...)
+		}
+	}
+
+	return upstream
+}
+
+// validateSteps validates steps in order of execution, precedingSteps maps the slugs of the steps
+// that run before the first of the given steps to the names of their outputs
+func validateSteps(definedCommands map[string]struct{}, definedSequences map[string]struct{}, precedingSteps map[string]map[string]struct{}, steps []StepConfig) error {
+	var errs ValidationErrors
+
+	preceding := map[string]map[string]struct{}{}
+	for slug, outputs := range precedingSteps {
+		preceding[slug] = outputs
+	}
+
+	for i := range steps {
+		err := validateStep(definedCommands, definedSequences, preceding, steps[i])
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate step name=%v", steps[i].Name), err)...)
+
+		if steps[i].Slug != "" {
+			preceding[steps[i].Slug] = stepOutputNames(steps[i])
+		}
+	}
+
+	return errs.orNil()
+}
+
+func stepOutputNames(step StepConfig) map[string]struct{} {
+	names := map[string]struct{}{}
+	for i := range step.Outputs {
+		names[step.Outputs[i].Name] = struct{}{}
+	}
+
+	return names
+}
+
+func validateStep(definedCommands map[string]struct{}, definedSequences map[string]struct{}, precedingSteps map[string]map[string]struct{}, step StepConfig) error {
+	var errs ValidationErrors
+
 	if step.Name == "" {
-		return errors.New("name is empty")
+		errs = append(errs, fieldErrorf("name", "name is empty"))
 	}
 
-	_, defined := definedCommands[step.CommandSlug]
-	if !defined {
-		return errors.Errorf("undefined command=%v", step.CommandSlug)
+	switch {
+	case step.CommandSlug != "" && step.SequenceSlug != "":
+		errs = append(errs, fieldErrorf("", "command and sequence set"))
+	case step.CommandSlug == "" && step.SequenceSlug == "":
+		errs = append(errs, fieldErrorf("", "no command and no sequence set"))
+	case step.CommandSlug != "":
+		_, defined := definedCommands[step.CommandSlug]
+		if !defined {
+			errs = append(errs, fieldErrorf("command", "undefined command=%v", step.CommandSlug))
+		}
+	case step.SequenceSlug != "":
+		_, defined := definedSequences[step.SequenceSlug]
+		if !defined {
+			errs = append(errs, fieldErrorf("sequence", "undefined sequence=%v", step.SequenceSlug))
+		}
+
+		if step.Foreach != nil {
+			errs = append(errs, fieldErrorf("foreach", "foreach is not supported on sequence steps"))
+		}
+
+		if step.Retries != 0 {
+			errs = append(errs, fieldErrorf("retries", "retries are not supported on sequence steps"))
+		}
+
+		if len(step.Outputs) != 0 {
+			errs = append(errs, fieldErrorf("outputs", "outputs are not supported on sequence steps"))
+		}
 	}
 
-	return nil
+	if step.Foreach != nil {
+		err := validateStepForeach(*step.Foreach)
+		errs = append(errs, atPath("foreach", "failed to validate foreach", err)...)
+	}
+
+	if step.Retries < 0 {
+		errs = append(errs, fieldErrorf("retries", "negative retries=%v", step.Retries))
+	}
+
+	if step.Backoff < 0 {
+		errs = append(errs, fieldErrorf("backoff", "negative backoff=%v", step.Backoff))
+	}
+
+	for i := range step.When {
+		_, defined := precedingSteps[step.When[i].StepSlug]
+		if !defined {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("when[%v].step", i), "condition refers to step=%v that is undefined or does not run before", step.When[i].StepSlug))
+		}
+
+		if len(step.When[i].ExitCodes) == 0 {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("when[%v]", i), "condition on step=%v has no exit codes", step.When[i].StepSlug))
+		}
+	}
+
+	for i := range step.Inputs {
+		path := fmt.Sprintf("inputs[%v]", i)
+
+		if step.Inputs[i].InputSlug == "" {
+			errs = append(errs, fieldErrorf(path, "input slug is empty"))
+
+			continue
+		}
+
+		switch {
+		case step.Inputs[i].Output != "" && step.Inputs[i].FromInput != "":
+			errs = append(errs, fieldErrorf(path, "input=%v has output and fromInput set", step.Inputs[i].InputSlug))
+
+			continue
+		case step.Inputs[i].Output == "" && step.Inputs[i].FromInput == "":
+			errs = append(errs, fieldErrorf(path, "input=%v has no output and no fromInput set", step.Inputs[i].InputSlug))
+
+			continue
+		case step.Inputs[i].FromInput != "":
+			continue
+		}
+
+		stepSlug, outputName := splitOutputReference(step.Inputs[i].Output)
+		outputs, defined := precedingSteps[stepSlug]
+		if !defined {
+			errs = append(errs, fieldErrorf(path+".output", "input=%v refers to output=%v of step=%v that is undefined or does not run before", step.Inputs[i].InputSlug, step.Inputs[i].Output, stepSlug))
+
+			continue
+		}
+
+		_, defined = outputs[outputName]
+		if !defined {
+			errs = append(errs, fieldErrorf(path+".output", "input=%v refers to undefined output=%v", step.Inputs[i].InputSlug, step.Inputs[i].Output))
+		}
+	}
+
+	seenOutputs := map[string]struct{}{}
+	for i := range step.Outputs {
+		err := validateStepOutput(step.Outputs[i])
+		errs = append(errs, atPath(fmt.Sprintf("outputs[%v]", i), fmt.Sprintf("failed to validate output name=%v", step.Outputs[i].Name), err)...)
+
+		_, seen := seenOutputs[step.Outputs[i].Name]
+		if seen {
+			errs = append(errs, fieldErrorf(fmt.Sprintf("outputs[%v].name", i), "duplicate output name=%v", step.Outputs[i].Name))
+		}
+		seenOutputs[step.Outputs[i].Name] = struct{}{}
+	}
+
+	return errs.orNil()
+}
+
+func validateStepForeach(foreach StepForeachConfig) error {
+	var errs ValidationErrors
+
+	switch {
+	case foreach.InputSlug != "" && len(foreach.Values) != 0:
+		errs = append(errs, fieldErrorf("", "input and values set"))
+	case foreach.InputSlug == "" && len(foreach.Values) == 0:
+		errs = append(errs, fieldErrorf("", "no input and no values set"))
+	}
+
+	if !envNameRegexp.MatchString(foreach.As) {
+		errs = append(errs, fieldErrorf("as", "as=%v is not a valid environment variable name", foreach.As))
+	}
+
+	switch {
+	case foreach.MaxParallel < 0:
+		errs = append(errs, fieldErrorf("maxParallel", "maxParallel=%v is negative", foreach.MaxParallel))
+	case foreach.MaxParallel != 0 && !foreach.Parallel:
+		errs = append(errs, fieldErrorf("maxParallel", "maxParallel is set, but parallel is not"))
+	}
+
+	return errs.orNil()
+}
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateStepOutput(output StepOutputConfig) error {
+	var errs ValidationErrors
+
+	if !envNameRegexp.MatchString(output.Name) {
+		errs = append(errs, fieldErrorf("name", "name is not a valid environment variable name"))
+	}
+
+	if output.Regex != "" && output.JSONPath != "" {
+		errs = append(errs, fieldErrorf("", "regex and jsonPath set"))
+	}
+
+	if output.Regex != "" {
+		_, err := regexp.Compile(output.Regex)
+		if err != nil {
+			errs = append(errs, ValidationError{Path: "regex", Err: errors.Wrapf(err, "failed to compile regex=%v", output.Regex)})
+		}
+	}
+
+	if output.JSONPath != "" {
+		_, err := jsonpathutil.Parse(output.JSONPath)
+		if err != nil {
+			errs = append(errs, ValidationError{Path: "jsonPath", Err: errors.Wrapf(err, "failed to parse jsonPath=%v", output.JSONPath)})
+		}
+	}
+
+	return errs.orNil()
 }
 
 func validateCommands(definedInputs map[string]struct{}, commands []CommandConfig) error {
+	var errs ValidationErrors
+
 	for i := range commands {
 		err := validateCommand(definedInputs, commands[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate command slug=%v", commands[i].Slug)
-		}
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate command slug=%v", commands[i].Slug), err)...)
 	}
 
 	seenSlugs := map[string]struct{}{}
 	for i := range commands {
 		_, seen := seenSlugs[commands[i].Slug]
 		if seen {
-			return errors.Errorf("duplicate slug=%v", commands[i].Slug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", commands[i].Slug))
 		}
 		seenSlugs[commands[i].Slug] = struct{}{}
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 func validateCommand(definedInputs map[string]struct{}, command CommandConfig) error {
+	var errs ValidationErrors
+
 	if command.Slug == "" {
-		return errors.New("slug is empty")
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
 	}
 
 	if command.Command == "" {
-		return errors.New("command is empty")
+		errs = append(errs, fieldErrorf("command", "command is empty"))
 	}
 
 	for i := range command.Inputs {
 		_, defined := definedInputs[command.Inputs[i].InputSlug]
 		if !defined {
-			return errors.Errorf("undefined input slug=%v", command.Inputs[i].InputSlug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("inputs[%v].input", i), "undefined input slug=%v", command.Inputs[i].InputSlug))
 		}
 	}
 
+	err := validateRedacts(command.Redact)
+	errs = append(errs, atPath("redact", "failed to validate redact rules", err)...)
+
 	seenInputs := map[string]struct{}{}
 	for i := range command.Inputs {
 		_, seen := seenInputs[command.Inputs[i].InputSlug]
 		if seen {
-			return errors.Errorf("duplicate input slug=%v", command.Inputs[i].InputSlug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("inputs[%v].input", i), "duplicate input slug=%v", command.Inputs[i].InputSlug))
 		}
 		seenInputs[command.Inputs[i].InputSlug] = struct{}{}
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 func validateInputs(inputs []InputConfig) error {
+	var errs ValidationErrors
+
 	for i := range inputs {
 		err := validateInput(inputs[i])
-		if err != nil {
-			return errors.Wrapf(err, "failed to validate input slug=%v", inputs[i].Slug)
-		}
+		errs = append(errs, atPath(fmt.Sprintf("[%v]", i), fmt.Sprintf("failed to validate input slug=%v", inputs[i].Slug), err)...)
 	}
 
 	seenSlugs := map[string]struct{}{}
 	for i := range inputs {
 		_, seen := seenSlugs[inputs[i].Slug]
 		if seen {
-			return errors.Errorf("duplicate slug=%v", inputs[i].Slug)
+			errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].slug", i), "duplicate slug=%v", inputs[i].Slug))
 		}
 		seenSlugs[inputs[i].Slug] = struct{}{}
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 func validateInput(input InputConfig) error {
+	var errs ValidationErrors
+
 	if input.Slug == "" {
-		return errors.New("slug is empty")
+		errs = append(errs, fieldErrorf("slug", "slug is empty"))
 	}
 
-	return nil
+	switch input.Type {
+	case "", domain.InputTypeString, domain.InputTypeInt, domain.InputTypeBool, domain.InputTypeMultiline, domain.InputTypeDate, domain.InputTypeSecret:
+		if len(input.Values) != 0 {
+			errs = append(errs, fieldErrorf("values", "values set for type=%v", input.Type))
+		}
+	case domain.InputTypeEnum:
+		if len(input.Values) == 0 && input.OptionsFrom == "" {
+			errs = append(errs, fieldErrorf("type", "neither values nor optionsFrom set for type=enum"))
+		}
+	default:
+		errs = append(errs, fieldErrorf("type", "unknown type=%v", input.Type))
+	}
+
+	// defaults are served to the browser with the view configs
+	if input.Default != "" && input.Type == domain.InputTypeSecret {
+		errs = append(errs, fieldErrorf("default", "default set for type=%v", input.Type))
+	}
+
+	if input.Default != "" {
+		_, err := domain.InputConfig{Type: input.Type, Values: input.Values, OptionsCommandSlug: input.OptionsFrom}.Convert(input.Default)
+		if err != nil {
+			errs = append(errs, ValidationError{Path: "default", Err: errors.Wrapf(err, "invalid default")})
+		}
+	}
+
+	if input.OptionsTTL < 0 {
+		errs = append(errs, fieldErrorf("optionsTTL", "negative optionsTTL=%v", input.OptionsTTL))
+	}
+
+	if input.OptionsTTL != 0 && input.OptionsFrom == "" {
+		errs = append(errs, fieldErrorf("optionsTTL", "optionsTTL set without optionsFrom"))
+	}
+
+	return errs.orNil()
+}
+
+// validateInputOptions validates that options are listed by defined commands and
+// that listing the options of an input doesn't depend on the input itself
+func validateInputOptions(inputs []InputConfig, commands []CommandConfig) error {
+	var errs ValidationErrors
+
+	commandsM := map[string]CommandConfig{}
+	for i := range commands {
+		commandsM[commands[i].Slug] = commands[i]
+	}
+
+	indexes := map[string]int{}
+	edges := map[string][]string{}
+	for i := range inputs {
+		indexes[inputs[i].Slug] = i
+
+		if inputs[i].OptionsFrom == "" {
+			continue
+		}
+
+		path := fmt.Sprintf("[%v].optionsFrom", i)
+
+		command, defined := commandsM[inputs[i].OptionsFrom]
+		if !defined {
+			errs = append(errs, fieldErrorf(path, "undefined optionsFrom command=%v of input slug=%v", inputs[i].OptionsFrom, inputs[i].Slug))
+
+			continue
+		}
+
+		if command.RequiresApproval != nil {
+			errs = append(errs, fieldErrorf(path, "optionsFrom command=%v of input slug=%v requires approval", command.Slug, inputs[i].Slug))
+		}
+
+		for j := range command.Inputs {
+			edges[inputs[i].Slug] = append(edges[inputs[i].Slug], command.Inputs[j].InputSlug)
+		}
+	}
+
+	cycle := findCycle(edges)
+	if cycle != nil {
+		errs = append(errs, fieldErrorf(fmt.Sprintf("[%v].optionsFrom", indexes[cycle[0]]), "options of inputs depend on each other inputs=%v", strings.Join(cycle, " -> ")))
+	}
+
+	return errs.orNil()
+}
+
+func validateSecrets(secrets []SecretConfig) error {
+	var errs ValidationErrors
+
+	seenSlugs := map[string]struct{}{}
+	for i := range secrets {
+		path := fmt.Sprintf("[%v]", i)
+
+		if secrets[i].Slug == "" {
+			errs = append(errs, fieldErrorf(path+".slug", "slug is empty"))
+		}
+
+		_, seen := seenSlugs[secrets[i].Slug]
+		if seen {
+			errs = append(errs, fieldErrorf(path+".slug", "duplicate slug=%v", secrets[i].Slug))
+		}
+		seenSlugs[secrets[i].Slug] = struct{}{}
+
+		switch {
+		case secrets[i].FromEnv != "" && secrets[i].FromFile != "":
+			errs = append(errs, fieldErrorf(path, "fromEnv and fromFile set for secret slug=%v", secrets[i].Slug))
+		case secrets[i].FromEnv == "" && secrets[i].FromFile == "":
+			errs = append(errs, fieldErrorf(path, "neither fromEnv nor fromFile set for secret slug=%v", secrets[i].Slug))
+		}
+	}
+
+	return errs.orNil()
+}
+
+func validateCommandSecrets(definedSecrets map[string]struct{}, commands []CommandConfig) error {
+	var errs ValidationErrors
+
+	for i, command := range commands {
+		seenEnvs := map[string]struct{}{}
+		for j := range command.Inputs {
+			seenEnvs[command.Inputs[j].InputSlug] = struct{}{}
+		}
+
+		for j := range command.Secrets {
+			path := fmt.Sprintf("[%v].secrets[%v]", i, j)
+
+			_, defined := definedSecrets[command.Secrets[j].SecretSlug]
+			if !defined {
+				errs = append(errs, fieldErrorf(path+".secret", "undefined secret=%v of command slug=%v", command.Secrets[j].SecretSlug, command.Slug))
+			}
+
+			env := secretEnv(command.Secrets[j])
+			if !envNameRegexp.MatchString(env) {
+				errs = append(errs, fieldErrorf(path+".env", "invalid env=%v of secret=%v of command slug=%v", env, command.Secrets[j].SecretSlug, command.Slug))
+			}
+
+			_, seen := seenEnvs[env]
+			if seen {
+				errs = append(errs, fieldErrorf(path+".env", "duplicate env=%v of command slug=%v", env, command.Slug))
+			}
+			seenEnvs[env] = struct{}{}
+		}
+	}
+
+	return errs.orNil()
+}
+
+func validateRedacts(redacts []RedactConfig) error {
+	var errs ValidationErrors
+
+	for i, r := range redacts {
+		path := fmt.Sprintf("[%v]", i)
+
+		switch {
+		case r.Regex != "" && r.Detector != "":
+			errs = append(errs, fieldErrorf(path, "regex and detector set for redact rule=%v", i))
+		case r.Regex == "" && r.Detector == "":
+			errs = append(errs, fieldErrorf(path, "neither regex nor detector set for redact rule=%v", i))
+		case r.Detector != "":
+			_, ok := redactDetectors[r.Detector]
+			if !ok {
+				errs = append(errs, fieldErrorf(path+".detector", "unknown detector=%v of redact rule=%v", r.Detector, i))
+			}
+		case r.Regex != "":
+			_, err := regexp.Compile(r.Regex)
+			if err != nil {
+				errs = append(errs, ValidationError{Path: path + ".regex", Err: errors.Wrapf(err, "failed to compile regex=%v of redact rule=%v", r.Regex, i)})
+			}
+		}
+	}
+
+	return errs.orNil()
 }
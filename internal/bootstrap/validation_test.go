@@ -1,7 +1,9 @@
 package bootstrap
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,6 +27,17 @@ func Test_ValidateInputConfigs(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "default of secret",
+			inputs: []InputConfig{
+				{
+					Slug:    "A",
+					Type:    "secret",
+					Default: "s3cr3t",
+				},
+			},
+			expectErr: true,
+		},
 		{
 			name: "missing slug",
 			inputs: []InputConfig{
@@ -49,6 +62,69 @@ func Test_ValidateInputConfigs(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "typed with defaults",
+			inputs: []InputConfig{
+				{
+					Slug:    "A",
+					Type:    "int",
+					Default: "3",
+				},
+				{
+					Slug:    "B",
+					Type:    "enum",
+					Values:  []string{"a", "b"},
+					Default: "b",
+				},
+				{
+					Slug:    "C",
+					Type:    "date",
+					Default: "2022-01-31",
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "unknown type",
+			inputs: []InputConfig{
+				{
+					Slug: "A",
+					Type: "float",
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "enum without values",
+			inputs: []InputConfig{
+				{
+					Slug: "A",
+					Type: "enum",
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "values without enum",
+			inputs: []InputConfig{
+				{
+					Slug:   "A",
+					Values: []string{"a"},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "default of wrong type",
+			inputs: []InputConfig{
+				{
+					Slug:    "A",
+					Type:    "bool",
+					Default: "maybe",
+				},
+			},
+			expectErr: true,
+		},
 	}
 
 	for i := range tcs {
@@ -178,6 +254,71 @@ func Test_ValidateCommandConfigs(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "redact rules",
+			commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Command: "A",
+					Redact: []RedactConfig{
+						{
+							Regex:       `(password=)\S+`,
+							Replacement: "${1}***",
+						},
+						{
+							Detector: "github-token",
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "redact rule with regex and detector",
+			commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Command: "A",
+					Redact: []RedactConfig{
+						{
+							Regex:    `password`,
+							Detector: "jwt",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "redact rule with unknown detector",
+			commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Command: "A",
+					Redact: []RedactConfig{
+						{
+							Detector: "unknown",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "redact rule with invalid regex",
+			commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Command: "A",
+					Redact: []RedactConfig{
+						{
+							Regex: `(`,
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 	}
 
 	for i := range tcs {
@@ -196,112 +337,1681 @@ func Test_ValidateCommandConfigs(t *testing.T) {
 	}
 }
 
-func Test_ValidateShellpaneConfig(t *testing.T) {
-	t.Run("valid", func(t *testing.T) {
-		config := ShellpaneConfig{
-			Inputs: []InputConfig{
-				{
-					Slug: "input-a",
-				},
-			},
-			Commands: []CommandConfig{
+func Test_ValidateSequenceConfigs(t *testing.T) {
+	definedCommands := map[string]struct{}{
+		"A": {},
+	}
+
+	tcs := []struct {
+		name      string
+		sequences []SequenceConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			sequences: []SequenceConfig{
 				{
-					Slug:    "command-a",
-					Command: "A",
-					Inputs: []CommandInputConfig{
+					Slug: "A",
+					Steps: []StepConfig{
 						{
-							InputSlug: "input-a",
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+							Retries:     2,
+							Backoff:     time.Second,
+						},
+						{
+							Name:            "B",
+							CommandSlug:     "A",
+							ContinueOnError: true,
+							When: []StepConditionConfig{
+								{
+									StepSlug:  "a",
+									ExitCodes: []int{0},
+								},
+							},
+						},
+					},
+					OnFailure: []StepConfig{
+						{
+							Name:        "C",
+							CommandSlug: "A",
+							When: []StepConditionConfig{
+								{
+									StepSlug:  "a",
+									ExitCodes: []int{1},
+								},
+							},
+						},
+					},
+					Finally: []StepConfig{
+						{
+							Name:        "D",
+							CommandSlug: "A",
 						},
 					},
 				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "no steps",
+			sequences: []SequenceConfig{
 				{
-					Slug:    "command-b",
-					Command: "B",
+					Slug: "A",
 				},
 			},
-			Categories: []CategoryConfig{
+			expectErr: true,
+		},
+		{
+			name: "negative retries",
+			sequences: []SequenceConfig{
 				{
-					Slug:  "category-a",
-					Name:  "A",
-					Color: "green",
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Retries:     -1,
+						},
+					},
 				},
 			},
-			Sequences: []SequenceConfig{
+			expectErr: true,
+		},
+		{
+			name: "duplicate step slug",
+			sequences: []SequenceConfig{
 				{
-					Slug: "sequence-a",
+					Slug: "A",
 					Steps: []StepConfig{
 						{
+							Slug:        "a",
 							Name:        "A",
-							CommandSlug: "command-a",
+							CommandSlug: "A",
 						},
+					},
+					Finally: []StepConfig{
 						{
+							Slug:        "a",
 							Name:        "B",
-							CommandSlug: "command-b",
+							CommandSlug: "A",
 						},
 					},
 				},
 			},
-			Views: []ViewConfig{
-				{
-					Slug:         "view-a",
-					Name:         "A",
-					CategorySlug: "category-a",
-					CommandSlug:  "command-a",
-				},
+			expectErr: true,
+		},
+		{
+			name: "condition on undefined step",
+			sequences: []SequenceConfig{
 				{
-					Slug:         "view-b",
-					Name:         "B",
-					CategorySlug: "category-a",
-					SequenceSlug: "sequence-a",
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							When: []StepConditionConfig{
+								{
+									StepSlug:  "b",
+									ExitCodes: []int{0},
+								},
+							},
+						},
+					},
 				},
 			},
-		}
-
-		err := ValidateShellpaneConfig(config)
-		require.NoError(t, err)
-	})
-
-	t.Run("duplicate command slugs", func(t *testing.T) {
-		config := ShellpaneConfig{
-			Inputs: []InputConfig{
+			expectErr: true,
+		},
+		{
+			name: "condition on later step",
+			sequences: []SequenceConfig{
 				{
 					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							When: []StepConditionConfig{
+								{
+									StepSlug:  "b",
+									ExitCodes: []int{0},
+								},
+							},
+						},
+						{
+							Slug:        "b",
+							Name:        "B",
+							CommandSlug: "A",
+						},
+					},
 				},
 			},
-			Commands: []CommandConfig{
+			expectErr: true,
+		},
+		{
+			name: "condition without exit codes",
+			sequences: []SequenceConfig{
 				{
-					Slug:    "A",
-					Command: "A",
-					Inputs: []CommandInputConfig{
+					Slug: "A",
+					Steps: []StepConfig{
 						{
-							InputSlug: "A",
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+						},
+						{
+							Name:        "B",
+							CommandSlug: "A",
+							When: []StepConditionConfig{
+								{
+									StepSlug: "a",
+								},
+							},
 						},
 					},
 				},
-				{
-					Slug:    "A",
-					Command: "A",
-				},
 			},
-		}
-
-		err := ValidateShellpaneConfig(config)
-		require.Error(t, err)
-	})
-
-	t.Run("duplicate input slugs", func(t *testing.T) {
-		config := ShellpaneConfig{
-			Inputs: []InputConfig{
+			expectErr: true,
+		},
+		{
+			name: "valid outputs",
+			sequences: []SequenceConfig{
 				{
 					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+							Outputs: []StepOutputConfig{
+								{
+									Name: "STDOUT",
+								},
+								{
+									Name:  "VERSION",
+									Regex: `version: (\S+)`,
+								},
+								{
+									Name:     "IMAGE",
+									JSONPath: "$.images[0].name",
+								},
+							},
+						},
+						{
+							Name:        "B",
+							CommandSlug: "A",
+							Inputs: []StepInputConfig{
+								{
+									InputSlug: "TAG",
+									Output:    "a.VERSION",
+								},
+							},
+						},
+					},
 				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "input refers to undefined output",
+			sequences: []SequenceConfig{
 				{
 					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+						},
+						{
+							Name:        "B",
+							CommandSlug: "A",
+							Inputs: []StepInputConfig{
+								{
+									InputSlug: "TAG",
+									Output:    "a.VERSION",
+								},
+							},
+						},
+					},
 				},
 			},
-		}
-
-		err := ValidateShellpaneConfig(config)
+			expectErr: true,
+		},
+		{
+			name: "input refers to output of later step",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Inputs: []StepInputConfig{
+								{
+									InputSlug: "TAG",
+									Output:    "b.VERSION",
+								},
+							},
+						},
+						{
+							Slug:        "b",
+							Name:        "B",
+							CommandSlug: "A",
+							Outputs: []StepOutputConfig{
+								{
+									Name: "VERSION",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "output with regex and json path",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Outputs: []StepOutputConfig{
+								{
+									Name:     "VERSION",
+									Regex:    "(.*)",
+									JSONPath: "version",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "output with invalid regex",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Outputs: []StepOutputConfig{
+								{
+									Name:  "VERSION",
+									Regex: "(",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "output with invalid name",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Outputs: []StepOutputConfig{
+								{
+									Name: "1-VERSION",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid needs",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+							Outputs: []StepOutputConfig{
+								{
+									Name: "VERSION",
+								},
+							},
+						},
+						{
+							Slug:        "b",
+							Name:        "B",
+							CommandSlug: "A",
+						},
+						{
+							Name:        "C",
+							CommandSlug: "A",
+							Needs:       []string{"b", "d"},
+							Inputs: []StepInputConfig{
+								{
+									InputSlug: "TAG",
+									Output:    "a.VERSION",
+								},
+							},
+						},
+						{
+							Slug:        "d",
+							Name:        "D",
+							CommandSlug: "A",
+							Needs:       []string{"a"},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "needs undefined step",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+							Needs:       []string{"b"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "needs form a cycle",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+							Needs:       []string{"c"},
+						},
+						{
+							Slug:        "b",
+							Name:        "B",
+							CommandSlug: "A",
+							Needs:       []string{"a"},
+						},
+						{
+							Slug:        "c",
+							Name:        "C",
+							CommandSlug: "A",
+							Needs:       []string{"b"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "condition on step that runs in parallel",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+						},
+						{
+							Slug:        "b",
+							Name:        "B",
+							CommandSlug: "A",
+						},
+						{
+							Name:        "C",
+							CommandSlug: "A",
+							Needs:       []string{"b"},
+							When: []StepConditionConfig{
+								{
+									StepSlug:  "a",
+									ExitCodes: []int{0},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "cleanup step with needs",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+						},
+					},
+					Finally: []StepConfig{
+						{
+							Name:        "B",
+							CommandSlug: "A",
+							Needs:       []string{"a"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid foreach",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Foreach: &StepForeachConfig{
+								Values: []string{"a", "b"},
+								As:     "ITEM",
+							},
+						},
+						{
+							Name:        "B",
+							CommandSlug: "A",
+							Foreach: &StepForeachConfig{
+								InputSlug: "NAMESPACES",
+								As:        "NAMESPACE",
+								Parallel:  true,
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "foreach with input and values",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Foreach: &StepForeachConfig{
+								InputSlug: "NAMESPACES",
+								Values:    []string{"a", "b"},
+								As:        "NAMESPACE",
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "foreach without as",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Foreach: &StepForeachConfig{
+								Values: []string{"a", "b"},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "foreach with negative maxParallel",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Foreach: &StepForeachConfig{
+								Values:      []string{"a", "b"},
+								As:          "NAMESPACE",
+								Parallel:    true,
+								MaxParallel: -1,
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "foreach with maxParallel but not parallel",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+							Foreach: &StepForeachConfig{
+								Values:      []string{"a", "b"},
+								As:          "NAMESPACE",
+								MaxParallel: 2,
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid included sequence",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:         "A",
+							SequenceSlug: "B",
+							Inputs: []StepInputConfig{
+								{
+									InputSlug: "NAMESPACE",
+									FromInput: "TARGET_NAMESPACE",
+								},
+							},
+						},
+					},
+				},
+				{
+					Slug: "B",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "undefined included sequence",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:         "A",
+							SequenceSlug: "B",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "command and sequence set",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:         "A",
+							CommandSlug:  "A",
+							SequenceSlug: "B",
+						},
+					},
+				},
+				{
+					Slug: "B",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "recursive included sequence",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Name:         "A",
+							SequenceSlug: "B",
+						},
+					},
+				},
+				{
+					Slug: "B",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "A",
+						},
+					},
+					Finally: []StepConfig{
+						{
+							Name:         "B",
+							SequenceSlug: "A",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "input with output and from input",
+			sequences: []SequenceConfig{
+				{
+					Slug: "A",
+					Steps: []StepConfig{
+						{
+							Slug:        "a",
+							Name:        "A",
+							CommandSlug: "A",
+							Outputs: []StepOutputConfig{
+								{
+									Name: "VERSION",
+								},
+							},
+						},
+						{
+							Name:        "B",
+							CommandSlug: "A",
+							Inputs: []StepInputConfig{
+								{
+									InputSlug: "TAG",
+									Output:    "a.VERSION",
+									FromInput: "VERSION",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateSequences(definedCommands, tcs[i].sequences)
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateShellpaneConfig(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		config := ShellpaneConfig{
+			Inputs: []InputConfig{
+				{
+					Slug: "input-a",
+				},
+			},
+			Commands: []CommandConfig{
+				{
+					Slug:    "command-a",
+					Command: "A",
+					Inputs: []CommandInputConfig{
+						{
+							InputSlug: "input-a",
+						},
+					},
+				},
+				{
+					Slug:    "command-b",
+					Command: "B",
+				},
+			},
+			Categories: []CategoryConfig{
+				{
+					Slug:  "category-a",
+					Name:  "A",
+					Color: "green",
+				},
+			},
+			Sequences: []SequenceConfig{
+				{
+					Slug: "sequence-a",
+					Steps: []StepConfig{
+						{
+							Name:        "A",
+							CommandSlug: "command-a",
+						},
+						{
+							Name:        "B",
+							CommandSlug: "command-b",
+						},
+					},
+				},
+			},
+			Views: []ViewConfig{
+				{
+					Slug:         "view-a",
+					Name:         "A",
+					CategorySlug: "category-a",
+					CommandSlug:  "command-a",
+				},
+				{
+					Slug:         "view-b",
+					Name:         "B",
+					CategorySlug: "category-a",
+					SequenceSlug: "sequence-a",
+				},
+			},
+		}
+
+		err := ValidateShellpaneConfig(config)
+		require.NoError(t, err)
+	})
+
+	t.Run("duplicate command slugs", func(t *testing.T) {
+		config := ShellpaneConfig{
+			Inputs: []InputConfig{
+				{
+					Slug: "A",
+				},
+			},
+			Commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Command: "A",
+					Inputs: []CommandInputConfig{
+						{
+							InputSlug: "A",
+						},
+					},
+				},
+				{
+					Slug:    "A",
+					Command: "A",
+				},
+			},
+		}
+
+		err := ValidateShellpaneConfig(config)
 		require.Error(t, err)
 	})
+
+	t.Run("collects all errors", func(t *testing.T) {
+		config := ShellpaneConfig{
+			Inputs: []InputConfig{
+				{
+					Slug: "A",
+					Type: "unknown",
+				},
+			},
+			Commands: []CommandConfig{
+				{
+					Slug: "A",
+				},
+			},
+			Views: []ViewConfig{
+				{
+					Slug:         "view-a",
+					Name:         "A",
+					CategorySlug: "undefined",
+					CommandSlug:  "A",
+				},
+			},
+		}
+
+		err := ValidateShellpaneConfig(config)
+		require.Error(t, err)
+
+		errs, ok := err.(ValidationErrors)
+		require.True(t, ok)
+
+		paths := make([]string, len(errs))
+		for i := range errs {
+			paths[i] = errs[i].Path
+		}
+
+		assert.Equal(t, []string{"inputs[0].type", "commands[0].command", "views[0].category"}, paths)
+	})
+
+	t.Run("duplicate input slugs", func(t *testing.T) {
+		config := ShellpaneConfig{
+			Inputs: []InputConfig{
+				{
+					Slug: "A",
+				},
+				{
+					Slug: "A",
+				},
+			},
+		}
+
+		err := ValidateShellpaneConfig(config)
+		require.Error(t, err)
+	})
+}
+
+func Test_ValidateApprovalConfigs(t *testing.T) {
+	tcs := []struct {
+		name      string
+		commands  []CommandConfig
+		sequences []SequenceConfig
+		views     []ViewConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			commands: []CommandConfig{
+				{
+					Slug: "A",
+					RequiresApproval: &ApprovalConfig{
+						Roles:  []ApprovalRoleConfig{{RoleSlug: "approver"}},
+						Expiry: time.Minute,
+					},
+				},
+			},
+			views: []ViewConfig{
+				{
+					Slug:        "A",
+					CommandSlug: "B",
+					RequiresApproval: &ApprovalConfig{
+						Roles: []ApprovalRoleConfig{{RoleSlug: "approver"}},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "no roles",
+			commands: []CommandConfig{
+				{
+					Slug:             "A",
+					RequiresApproval: &ApprovalConfig{},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "undefined role",
+			commands: []CommandConfig{
+				{
+					Slug: "A",
+					RequiresApproval: &ApprovalConfig{
+						Roles: []ApprovalRoleConfig{{RoleSlug: "undefined"}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative expiry",
+			commands: []CommandConfig{
+				{
+					Slug: "A",
+					RequiresApproval: &ApprovalConfig{
+						Roles:  []ApprovalRoleConfig{{RoleSlug: "approver"}},
+						Expiry: -time.Minute,
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "command of a view that requires approval is a sequence step",
+			views: []ViewConfig{
+				{
+					Slug:        "A",
+					CommandSlug: "A",
+					RequiresApproval: &ApprovalConfig{
+						Roles: []ApprovalRoleConfig{{RoleSlug: "approver"}},
+					},
+				},
+			},
+			sequences: []SequenceConfig{
+				{
+					Slug:  "A",
+					Steps: []StepConfig{{Slug: "a", CommandSlug: "A"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "command is a sequence step",
+			commands: []CommandConfig{
+				{
+					Slug: "A",
+					RequiresApproval: &ApprovalConfig{
+						Roles: []ApprovalRoleConfig{{RoleSlug: "approver"}},
+					},
+				},
+			},
+			sequences: []SequenceConfig{
+				{
+					Slug:    "A",
+					Finally: []StepConfig{{Slug: "a", CommandSlug: "A"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "sequence view",
+			views: []ViewConfig{
+				{
+					Slug:         "A",
+					SequenceSlug: "A",
+					RequiresApproval: &ApprovalConfig{
+						Roles: []ApprovalRoleConfig{{RoleSlug: "approver"}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			definedRoles := map[string]struct{}{"approver": {}}
+
+			err := validateApprovals(definedRoles, tcs[i].commands, tcs[i].sequences, tcs[i].views)
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateInputOptionsConfigs(t *testing.T) {
+	tcs := []struct {
+		name      string
+		inputs    []InputConfig
+		commands  []CommandConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			inputs: []InputConfig{
+				{
+					Slug: "REGION",
+				},
+				{
+					Slug:        "CLUSTER",
+					OptionsFrom: "list-clusters",
+				},
+			},
+			commands: []CommandConfig{
+				{
+					Slug:   "list-clusters",
+					Inputs: []CommandInputConfig{{InputSlug: "REGION"}},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "undefined command",
+			inputs: []InputConfig{
+				{
+					Slug:        "CLUSTER",
+					OptionsFrom: "undefined",
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "command requires approval",
+			inputs: []InputConfig{
+				{
+					Slug:        "CLUSTER",
+					OptionsFrom: "list-clusters",
+				},
+			},
+			commands: []CommandConfig{
+				{
+					Slug: "list-clusters",
+					RequiresApproval: &ApprovalConfig{
+						Roles: []ApprovalRoleConfig{{RoleSlug: "approver"}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "options depend on input itself",
+			inputs: []InputConfig{
+				{
+					Slug:        "CLUSTER",
+					OptionsFrom: "list-clusters",
+				},
+			},
+			commands: []CommandConfig{
+				{
+					Slug:   "list-clusters",
+					Inputs: []CommandInputConfig{{InputSlug: "CLUSTER"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "options depend on each other",
+			inputs: []InputConfig{
+				{
+					Slug:        "REGION",
+					OptionsFrom: "list-regions",
+				},
+				{
+					Slug:        "CLUSTER",
+					OptionsFrom: "list-clusters",
+				},
+			},
+			commands: []CommandConfig{
+				{
+					Slug:   "list-regions",
+					Inputs: []CommandInputConfig{{InputSlug: "CLUSTER"}},
+				},
+				{
+					Slug:   "list-clusters",
+					Inputs: []CommandInputConfig{{InputSlug: "REGION"}},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateInputOptions(tcs[i].inputs, tcs[i].commands)
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateSecretConfigs(t *testing.T) {
+	tcs := []struct {
+		name      string
+		secrets   []SecretConfig
+		commands  []CommandConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			secrets: []SecretConfig{
+				{
+					Slug:    "DB_PASSWORD",
+					FromEnv: "DB_PASSWORD",
+				},
+				{
+					Slug:     "token",
+					FromFile: "/run/secrets/token",
+				},
+			},
+			commands: []CommandConfig{
+				{
+					Slug: "A",
+					Secrets: []CommandSecretConfig{
+						{SecretSlug: "DB_PASSWORD"},
+						{SecretSlug: "token", Env: "TOKEN"},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "duplicate slug",
+			secrets: []SecretConfig{
+				{Slug: "A", FromEnv: "A"},
+				{Slug: "A", FromEnv: "B"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "fromEnv and fromFile",
+			secrets: []SecretConfig{
+				{Slug: "A", FromEnv: "A", FromFile: "/a"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no source",
+			secrets: []SecretConfig{
+				{Slug: "A"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "undefined secret",
+			commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Secrets: []CommandSecretConfig{{SecretSlug: "undefined", Env: "A"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid env name",
+			secrets: []SecretConfig{
+				{Slug: "api-token", FromEnv: "TOKEN"},
+			},
+			commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Secrets: []CommandSecretConfig{{SecretSlug: "api-token"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "env of input",
+			secrets: []SecretConfig{
+				{Slug: "token", FromEnv: "TOKEN"},
+			},
+			commands: []CommandConfig{
+				{
+					Slug:    "A",
+					Inputs:  []CommandInputConfig{{InputSlug: "TOKEN"}},
+					Secrets: []CommandSecretConfig{{SecretSlug: "token", Env: "TOKEN"}},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateSecrets(tcs[i].secrets)
+			if err == nil {
+				definedSecrets := map[string]struct{}{}
+				for _, s := range tcs[i].secrets {
+					definedSecrets[s.Slug] = struct{}{}
+				}
+
+				err = validateCommandSecrets(definedSecrets, tcs[i].commands)
+			}
+
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateViewInputConfigs(t *testing.T) {
+	inputs := []InputConfig{
+		{
+			Slug: "SERVICE",
+		},
+		{
+			Slug: "REPLICAS",
+			Type: "int",
+		},
+		{
+			Slug: "TOKEN",
+			Type: "secret",
+		},
+	}
+
+	commands := []CommandConfig{
+		{
+			Slug:    "scale",
+			Command: "echo $SERVICE $REPLICAS",
+			Inputs:  []CommandInputConfig{{InputSlug: "SERVICE"}, {InputSlug: "REPLICAS"}, {InputSlug: "TOKEN"}},
+		},
+	}
+
+	tcs := []struct {
+		name      string
+		views     []ViewConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			views: []ViewConfig{
+				{
+					Slug:        "scale-api",
+					CommandSlug: "scale",
+					Inputs: []ViewInputConfig{
+						{InputSlug: "SERVICE", Value: "api", Locked: true},
+						{InputSlug: "REPLICAS", Value: "2"},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "input of another command",
+			views: []ViewConfig{
+				{
+					Slug:        "scale-api",
+					CommandSlug: "scale",
+					Inputs:      []ViewInputConfig{{InputSlug: "UNDEFINED", Value: "api"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "duplicate input",
+			views: []ViewConfig{
+				{
+					Slug:        "scale-api",
+					CommandSlug: "scale",
+					Inputs:      []ViewInputConfig{{InputSlug: "SERVICE", Value: "api"}, {InputSlug: "SERVICE", Value: "worker"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "preset secret input",
+			views: []ViewConfig{
+				{
+					Slug:        "scale-api",
+					CommandSlug: "scale",
+					Inputs:      []ViewInputConfig{{InputSlug: "TOKEN", Value: "s3cr3t"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "value of wrong type",
+			views: []ViewConfig{
+				{
+					Slug:        "scale-api",
+					CommandSlug: "scale",
+					Inputs:      []ViewInputConfig{{InputSlug: "REPLICAS", Value: "many"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "locked empty int",
+			views: []ViewConfig{
+				{
+					Slug:        "scale-api",
+					CommandSlug: "scale",
+					Inputs:      []ViewInputConfig{{InputSlug: "REPLICAS", Locked: true}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "view of a sequence",
+			views: []ViewConfig{
+				{
+					Slug:         "scale-api",
+					SequenceSlug: "scale",
+					Inputs:       []ViewInputConfig{{InputSlug: "SERVICE", Value: "api"}},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateViewInputs(inputs, commands, tcs[i].views)
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateCategoryConfigs(t *testing.T) {
+	tcs := []struct {
+		name       string
+		categories []CategoryConfig
+		expectErr  bool
+	}{
+		{
+			name: "valid",
+			categories: []CategoryConfig{
+				{Slug: "infra", Name: "infra", Color: "green", Order: 1},
+				{Slug: "db", Name: "db", Color: "green", Parent: "infra"},
+				{Slug: "postgres", Name: "postgres", Color: "green", Parent: "db"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "undefined parent",
+			categories: []CategoryConfig{
+				{Slug: "db", Name: "db", Color: "green", Parent: "infra"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "nested in itself",
+			categories: []CategoryConfig{
+				{Slug: "db", Name: "db", Color: "green", Parent: "db"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "cycle",
+			categories: []CategoryConfig{
+				{Slug: "infra", Name: "infra", Color: "green", Parent: "postgres"},
+				{Slug: "db", Name: "db", Color: "green", Parent: "infra"},
+				{Slug: "postgres", Name: "postgres", Color: "green", Parent: "db"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateCategories(tcs[i].categories)
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateGroupConfigs(t *testing.T) {
+	definedRoles := map[string]struct{}{"operator": {}}
+
+	tcs := []struct {
+		name      string
+		groups    []GroupConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			groups: []GroupConfig{
+				{Slug: "operators", Roles: []GroupRoleConfig{{RoleSlug: "operator"}}, HeaderGroups: []string{"ops", "sre"}},
+			},
+			expectErr: false,
+		},
+		{
+			name: "undefined role",
+			groups: []GroupConfig{
+				{Slug: "operators", Roles: []GroupRoleConfig{{RoleSlug: "admin"}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "empty header group",
+			groups: []GroupConfig{
+				{Slug: "operators", HeaderGroups: []string{""}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "duplicate header group",
+			groups: []GroupConfig{
+				{Slug: "operators", HeaderGroups: []string{"ops", "ops"}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateGroups(definedRoles, tcs[i].groups)
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateAPITokenConfigs(t *testing.T) {
+	definedUsers := map[string]struct{}{"ci": {}}
+	definedCommands := map[string]struct{}{"deploy": {}}
+	definedViews := map[string]struct{}{"deploy": {}}
+
+	hash := strings.Repeat("a", 64)
+	expiresAt := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name      string
+		tokens    []APITokenConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			tokens: []APITokenConfig{
+				{Slug: "ci-deploy", Owner: "ci", Hash: hash, ExpiresAt: expiresAt, Commands: []string{"deploy"}, Views: []string{"deploy"}},
+			},
+			expectErr: false,
+		},
+		{
+			name: "undefined owner",
+			tokens: []APITokenConfig{
+				{Slug: "ci-deploy", Owner: "cd", Hash: hash, ExpiresAt: expiresAt, Views: []string{"deploy"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid hash",
+			tokens: []APITokenConfig{
+				{Slug: "ci-deploy", Owner: "ci", Hash: "shellpane_token", ExpiresAt: expiresAt, Views: []string{"deploy"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no expiry",
+			tokens: []APITokenConfig{
+				{Slug: "ci-deploy", Owner: "ci", Hash: hash, Views: []string{"deploy"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no scopes",
+			tokens: []APITokenConfig{
+				{Slug: "ci-deploy", Owner: "ci", Hash: hash, ExpiresAt: expiresAt},
+			},
+			expectErr: true,
+		},
+		{
+			name: "undefined view",
+			tokens: []APITokenConfig{
+				{Slug: "ci-deploy", Owner: "ci", Hash: hash, ExpiresAt: expiresAt, Views: []string{"rollback"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "duplicate hash",
+			tokens: []APITokenConfig{
+				{Slug: "ci-deploy", Owner: "ci", Hash: hash, ExpiresAt: expiresAt, Views: []string{"deploy"}},
+				{Slug: "ci-deploy-2", Owner: "ci", Hash: strings.ToUpper(hash), ExpiresAt: expiresAt, Views: []string{"deploy"}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateAPITokens(definedUsers, definedCommands, definedViews, tcs[i].tokens)
+			if tcs[i].expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateRoleConstraintConfigs(t *testing.T) {
+	definedViews := map[string]struct{}{"scale": {}}
+	commands := []CommandConfig{
+		{Slug: "scale", Inputs: []CommandInputConfig{{InputSlug: "NAMESPACE"}}},
+	}
+	views := []ViewConfig{
+		{Slug: "scale", CommandSlug: "scale"},
+	}
+
+	role := func(constraints ...InputConstraintConfig) []RoleConfig {
+		return []RoleConfig{
+			{Slug: "operator", Views: []RoleViewConfig{{ViewSlug: "scale", Constraints: constraints}}},
+		}
+	}
+
+	tcs := []struct {
+		name      string
+		roles     []RoleConfig
+		expectErr bool
+	}{
+		{
+			name:      "valid values",
+			roles:     role(InputConstraintConfig{InputSlug: "NAMESPACE", Values: []string{"staging", "dev"}}),
+			expectErr: false,
+		},
+		{
+			name:      "valid regex",
+			roles:     role(InputConstraintConfig{InputSlug: "NAMESPACE", Regex: "dev-.*"}),
+			expectErr: false,
+		},
+		{
+			name: "valid expressions",
+			roles: role(
+				InputConstraintConfig{Expression: `NAMESPACE in ["staging", "dev"]`},
+				InputConstraintConfig{Expression: `NAMESPACE not in ["prod"]`},
+				InputConstraintConfig{Expression: `NAMESPACE == "dev"`},
+				InputConstraintConfig{Expression: `NAMESPACE != 1`},
+				InputConstraintConfig{Expression: `NAMESPACE matches "dev-.*"`},
+			),
+			expectErr: false,
+		},
+		{
+			name:      "neither values, regex nor expression",
+			roles:     role(InputConstraintConfig{InputSlug: "NAMESPACE"}),
+			expectErr: true,
+		},
+		{
+			name:      "values and regex",
+			roles:     role(InputConstraintConfig{InputSlug: "NAMESPACE", Values: []string{"dev"}, Regex: "dev"}),
+			expectErr: true,
+		},
+		{
+			name:      "empty input",
+			roles:     role(InputConstraintConfig{Values: []string{"dev"}}),
+			expectErr: true,
+		},
+		{
+			name:      "input and expression",
+			roles:     role(InputConstraintConfig{InputSlug: "NAMESPACE", Expression: `NAMESPACE == "dev"`}),
+			expectErr: true,
+		},
+		{
+			name:      "invalid regex",
+			roles:     role(InputConstraintConfig{InputSlug: "NAMESPACE", Regex: "("}),
+			expectErr: true,
+		},
+		{
+			name:      "invalid operator",
+			roles:     role(InputConstraintConfig{Expression: `NAMESPACE > "dev"`}),
+			expectErr: true,
+		},
+		{
+			name:      "in without array",
+			roles:     role(InputConstraintConfig{Expression: `NAMESPACE in "dev"`}),
+			expectErr: true,
+		},
+		{
+			name:      "invalid value",
+			roles:     role(InputConstraintConfig{Expression: `NAMESPACE in [staging]`}),
+			expectErr: true,
+		},
+		{
+			name:      "invalid expression regex",
+			roles:     role(InputConstraintConfig{Expression: `NAMESPACE matches "("`}),
+			expectErr: true,
+		},
+		{
+			name:      "input of another command",
+			roles:     role(InputConstraintConfig{InputSlug: "REPLICAS", Values: []string{"1"}}),
+			expectErr: true,
+		},
+		{
+			name:      "expression on input of another command",
+			roles:     role(InputConstraintConfig{Expression: `REPLICAS == 1`}),
+			expectErr: true,
+		},
+	}
+
+	for i := range tcs {
+		i := i
+
+		t.Run(tcs[i].name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := atPath("", "", validateRoles(map[string]struct{}{}, definedViews, tcs[i].roles))
+			errs = append(errs, atPath("", "", validateRoleConstraints(commands, nil, views, tcs[i].roles))...)
+			if tcs[i].expectErr {
+				assert.Error(t, errs.orNil())
+			} else {
+				assert.NoError(t, errs.orNil())
+			}
+		})
+	}
 }
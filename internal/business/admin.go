@@ -0,0 +1,149 @@
+package business
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+// ConfigAdmin changes the config at runtime. Entities are json objects with the keys of the config yaml,
+// kinds are the yaml keys of their lists, e.g. views. Changes are validated against the whole config
+// and fail with a conflict if revision isn't the current revision of the config.
+type ConfigAdmin interface {
+	GetConfigEntities(ctx context.Context, kind string) ([]json.RawMessage, string, error)
+	CreateConfigEntity(ctx context.Context, kind string, entity json.RawMessage, revision string) (string, error)
+	UpdateConfigEntity(ctx context.Context, kind string, id string, entity json.RawMessage, revision string) (string, error)
+	DeleteConfigEntity(ctx context.Context, kind string, id string, revision string) (string, error)
+}
+
+type GetConfigEntitiesRequest struct {
+	Kind string
+}
+
+type GetConfigEntitiesResponse struct {
+	errutil.Response
+	Revision string
+	Entities []json.RawMessage
+}
+
+func (h Handler) GetConfigEntities(ctx context.Context, req GetConfigEntitiesRequest) (GetConfigEntitiesResponse, error) {
+	err := h.authorizeAdmin(ctx)
+	if err != nil {
+		return GetConfigEntitiesResponse{}, errors.Wrapf(err, "failed to authorize admin")
+	}
+
+	entities, revision, err := h.opts.ConfigAdmin.GetConfigEntities(ctx, req.Kind)
+	if err != nil {
+		return GetConfigEntitiesResponse{}, errors.Wrapf(err, "failed to get config entities of kind=%v", req.Kind)
+	}
+
+	return GetConfigEntitiesResponse{Revision: revision, Entities: entities}, nil
+}
+
+type CreateConfigEntityRequest struct {
+	Kind string
+	// Revision is the revision of the config the entity was created for
+	Revision string
+	Entity   json.RawMessage
+}
+
+type CreateConfigEntityResponse struct {
+	errutil.Response
+	Revision string
+}
+
+func (h Handler) CreateConfigEntity(ctx context.Context, req CreateConfigEntityRequest) (CreateConfigEntityResponse, error) {
+	err := h.authorizeAdmin(ctx)
+	if err != nil {
+		return CreateConfigEntityResponse{}, errors.Wrapf(err, "failed to authorize admin")
+	}
+
+	revision, err := h.opts.ConfigAdmin.CreateConfigEntity(ctx, req.Kind, req.Entity, req.Revision)
+	if err != nil {
+		return CreateConfigEntityResponse{}, errors.Wrapf(err, "failed to create config entity of kind=%v", req.Kind)
+	}
+
+	return CreateConfigEntityResponse{Revision: revision}, nil
+}
+
+type UpdateConfigEntityRequest struct {
+	Kind string
+	// ID is the slug, or the id of a user, of the entity that is replaced by Entity
+	ID string
+	// Revision is the revision of the config the entity was changed for
+	Revision string
+	Entity   json.RawMessage
+}
+
+type UpdateConfigEntityResponse struct {
+	errutil.Response
+	Revision string
+}
+
+func (h Handler) UpdateConfigEntity(ctx context.Context, req UpdateConfigEntityRequest) (UpdateConfigEntityResponse, error) {
+	err := h.authorizeAdmin(ctx)
+	if err != nil {
+		return UpdateConfigEntityResponse{}, errors.Wrapf(err, "failed to authorize admin")
+	}
+
+	revision, err := h.opts.ConfigAdmin.UpdateConfigEntity(ctx, req.Kind, req.ID, req.Entity, req.Revision)
+	if err != nil {
+		return UpdateConfigEntityResponse{}, errors.Wrapf(err, "failed to update config entity of kind=%v id=%v", req.Kind, req.ID)
+	}
+
+	return UpdateConfigEntityResponse{Revision: revision}, nil
+}
+
+type DeleteConfigEntityRequest struct {
+	Kind string
+	ID   string
+	// Revision is the revision of the config the entity was deleted from
+	Revision string
+}
+
+type DeleteConfigEntityResponse struct {
+	errutil.Response
+	Revision string
+}
+
+func (h Handler) DeleteConfigEntity(ctx context.Context, req DeleteConfigEntityRequest) (DeleteConfigEntityResponse, error) {
+	err := h.authorizeAdmin(ctx)
+	if err != nil {
+		return DeleteConfigEntityResponse{}, errors.Wrapf(err, "failed to authorize admin")
+	}
+
+	revision, err := h.opts.ConfigAdmin.DeleteConfigEntity(ctx, req.Kind, req.ID, req.Revision)
+	if err != nil {
+		return DeleteConfigEntityResponse{}, errors.Wrapf(err, "failed to delete config entity of kind=%v id=%v", req.Kind, req.ID)
+	}
+
+	return DeleteConfigEntityResponse{Revision: revision}, nil
+}
+
+// authorizeAdmin fails unless the user of ctx is granted an admin role, without a user
+// the admin api is not available, as it allows to run arbitrary commands
+func (h Handler) authorizeAdmin(ctx context.Context) error {
+	userID := UserID(ctx)
+	if userID == "" {
+		return errutil.Unauthorized(errors.New("the admin api requires a user"))
+	}
+
+	_, ok := APIToken(ctx)
+	if ok {
+		return errutil.Unauthorized(errors.New("the admin api is not available to api tokens"))
+	}
+
+	user, ok := h.user(ctx)
+	if !ok || !user.IsAdmin() {
+		return errutil.Unauthorized(errors.Errorf("user=%v is not an admin", userID))
+	}
+
+	if h.opts.ConfigAdmin == nil {
+		return errors.New("no config admin configured")
+	}
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package business
 
-import "context"
+import (
+	"context"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+)
 
 type userIDKey struct{}
 
@@ -16,3 +20,32 @@ func UserID(ctx context.Context) string {
 
 	return v.(string)
 }
+
+type groupsKey struct{}
+
+// WithGroups sets the groups of the user as sent by a trusted proxy in the groups header
+func WithGroups(ctx context.Context, groups []string) context.Context {
+	return context.WithValue(ctx, groupsKey{}, groups)
+}
+
+func Groups(ctx context.Context) []string {
+	v := ctx.Value(groupsKey{})
+	if v == nil {
+		return nil
+	}
+
+	return v.([]string)
+}
+
+type apiTokenKey struct{}
+
+// WithAPIToken sets the api token the request is authenticated with, it limits the permissions of its owner
+func WithAPIToken(ctx context.Context, token domain.APIToken) context.Context {
+	return context.WithValue(ctx, apiTokenKey{}, token)
+}
+
+func APIToken(ctx context.Context) (domain.APIToken, bool) {
+	token, ok := ctx.Value(apiTokenKey{}).(domain.APIToken)
+
+	return token, ok
+}
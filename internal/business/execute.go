@@ -10,6 +10,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/ppwfx/shellpane/internal/domain"
 	"github.com/ppwfx/shellpane/internal/utils/errutil"
 )
 
@@ -18,7 +19,9 @@ const (
 )
 
 type ExecuteCommandRequest struct {
-	Slug   string
+	Slug string
+	// View is the slug of the view the command is executed through, the command defaults to the command of the view
+	View   string
 	Inputs []InputValue
 	Format string
 }
@@ -31,23 +34,55 @@ type InputValue struct {
 type ExecuteCommandResponse struct {
 	errutil.Response
 	Output CommandOutput
+	Run    *domain.Run
 }
 
 type CommandOutput struct {
 	Stdout   string
 	Stderr   string
 	ExitCode int
+	// Redactions counts the secrets and matches of redact rules that were replaced in stdout and stderr
+	Redactions int
 }
 
 func (h Handler) ExecuteCommand(ctx context.Context, req ExecuteCommandRequest) (ExecuteCommandResponse, error) {
 	userID := UserID(ctx)
-	if userID != "" {
-		allowedCommands := h.opts.Repository.GetUserAllowedCommands()[userID]
+
+	var view *domain.ViewConfig
+	switch {
+	case req.View != "":
+		v, ok := h.opts.Repository.GetViewConfigBySlug(req.View)
+		if !ok {
+			return ExecuteCommandResponse{}, errors.Wrapf(errutil.NotFound(errutil.Nil(), "View", req.View), "failed to find view slug=%v", req.View)
+		}
+
+		if req.Slug == "" {
+			req.Slug = v.Command.Slug
+		}
+
+		if v.Command.Slug == "" || v.Command.Slug != req.Slug {
+			return ExecuteCommandResponse{}, errutil.Invalid(errors.Errorf("view=%v doesn't run command=%v", req.View, req.Slug))
+		}
+
+		if userID != "" {
+			_, ok := h.allowedViews(ctx)[req.View]
+			if !ok {
+				return ExecuteCommandResponse{}, errutil.Unauthorized(errors.Errorf("user is not allowed to execute view user=%v view=%v", userID, req.View))
+			}
+		}
+
+		view = &v
+	case userID != "":
+		allowedCommands := h.allowedCommands(ctx)
 
 		_, ok := allowedCommands[req.Slug]
 		if !ok {
 			return ExecuteCommandResponse{}, errutil.Unauthorized(errors.Errorf("user is not allowed to execute command user=%v command=%v", userID, req.Slug))
 		}
+
+		if !h.isCommandUnlocked(ctx, req.Slug) {
+			return ExecuteCommandResponse{}, errutil.Unauthorized(errors.Errorf("user is only allowed to execute command through a view that locks inputs user=%v command=%v", userID, req.Slug))
+		}
 	}
 
 	command, ok := h.opts.Repository.GetCommandConfig(req.Slug)
@@ -55,12 +90,49 @@ func (h Handler) ExecuteCommand(ctx context.Context, req ExecuteCommandRequest)
 		return ExecuteCommandResponse{}, errors.Wrapf(errutil.NotFound(errutil.Nil(), "Command", req.Slug), "failed to find command slug=%v", req.Slug)
 	}
 
+	values := req.Inputs
+	if view != nil {
+		var err error
+		values, err = applyViewInputs(*view, command, values)
+		if err != nil {
+			return ExecuteCommandResponse{}, errors.Wrapf(err, "failed to apply view inputs")
+		}
+	}
+
+	inputs, err := convertInputValues(commandInputConfigs(command), values)
+	if err != nil {
+		return ExecuteCommandResponse{}, errors.Wrapf(err, "failed to convert input values")
+	}
+
+	err = h.validateInputValues(ctx, command, inputs)
+	if err != nil {
+		return ExecuteCommandResponse{}, errors.Wrapf(err, "failed to validate input values")
+	}
+
+	if userID != "" {
+		err = h.checkInputConstraints(ctx, view, command.Slug, inputs)
+		if err != nil {
+			return ExecuteCommandResponse{}, errors.Wrapf(err, "failed to check input constraints")
+		}
+	}
+
+	if command.RequiresApproval != nil {
+		run, err := h.requestRun(ctx, command, inputs)
+		if err != nil {
+			return ExecuteCommandResponse{}, errors.Wrapf(err, "failed to request run")
+		}
+
+		run = redactRun(command, run)
+
+		return ExecuteCommandResponse{Run: &run}, nil
+	}
+
 	//err := validateExecuteCommandRequest(command, req)
 	//if err != nil {
 	//	return ExecuteCommandResponse{}, errors.Wrapf(err, "failed to validate request")
 	//}
 
-	o, err := executeCommand(ctx, command.Command, req.Inputs)
+	o, err := executeCommandConfig(ctx, command, inputs, h.getSecrets(commandInputConfigs(command), inputs))
 	if err != nil {
 		return ExecuteCommandResponse{}, errors.Wrapf(err, "failed to execute command")
 	}
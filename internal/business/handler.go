@@ -13,8 +13,11 @@ type HandlerConfig struct {
 }
 
 type HandlerOpts struct {
-	Config     HandlerConfig
-	Repository persistence.Repository
+	Config            HandlerConfig
+	Repository        persistence.Repository
+	RunRepository     persistence.RunRepository
+	InputOptionsCache persistence.InputOptionsCache
+	ConfigAdmin       ConfigAdmin
 }
 
 type Handler struct {
@@ -46,7 +49,7 @@ func (h Handler) GetViewConfigs(ctx context.Context, req GetViewConfigsRequest)
 	case userID == "":
 		views = h.opts.Repository.GetViewConfigs()
 	case userID != "":
-		allowedViews := h.opts.Repository.GetUserAllowedViews()[userID]
+		allowedViews := h.allowedViews(ctx)
 		log = log.With("allowedViews", allowedViews)
 
 		views = h.opts.Repository.GetViewConfigsIn(allowedViews)
@@ -73,14 +76,25 @@ func (h Handler) GetCategoryConfigs(ctx context.Context, req GetCategoryConfigsR
 	case userID == "":
 		categories = h.opts.Repository.GetCategoryConfigs()
 	case userID != "":
-		allowedCategories := h.opts.Repository.GetUserAllowedCategories()[userID]
+		allowedCategories := h.allowedCategories(ctx)
 
 		categories = h.opts.Repository.GetCategoryConfigsIn(allowedCategories)
 	}
 
+	return GetCategoryConfigsResponse{CategoryConfigs: withoutViews(categories)}, nil
+}
+
+// withoutViews returns copies of the category tree without views, as views are returned by GetViewConfigs
+func withoutViews(categories []domain.CategoryConfig) []domain.CategoryConfig {
+	copied := make([]domain.CategoryConfig, len(categories))
 	for i := range categories {
-		categories[i].Views = nil
+		copied[i] = categories[i]
+		copied[i].Views = nil
+		copied[i].Categories = nil
+		if len(categories[i].Categories) > 0 {
+			copied[i].Categories = withoutViews(categories[i].Categories)
+		}
 	}
 
-	return GetCategoryConfigsResponse{CategoryConfigs: categories}, nil
+	return copied
 }
@@ -0,0 +1,69 @@
+package business
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+// convertInputValues sets the defaults of missing inputs and converts values to the canonical form of their input types,
+// values of undeclared inputs are passed through unchanged
+func convertInputValues(inputs []domain.InputConfig, values []InputValue) ([]InputValue, error) {
+	inputsM := map[string]domain.InputConfig{}
+	for i := range inputs {
+		inputsM[inputs[i].Slug] = inputs[i]
+	}
+
+	converted := []InputValue{}
+	seen := map[string]struct{}{}
+	for _, v := range values {
+		// the env of a command keeps the last of duplicate values, which would bypass checks of the first
+		_, ok := seen[v.Name]
+		if ok {
+			return nil, errutil.Invalid(errors.Errorf("duplicate value of input=%v", v.Name))
+		}
+		seen[v.Name] = struct{}{}
+
+		input, ok := inputsM[v.Name]
+		if !ok {
+			converted = append(converted, v)
+
+			continue
+		}
+
+		value, err := input.Convert(v.Value)
+		if err != nil {
+			return nil, errutil.Invalid(errors.Wrapf(err, "failed to convert value of input=%v", v.Name))
+		}
+
+		converted = append(converted, InputValue{Name: v.Name, Value: value})
+	}
+
+	for i := range inputs {
+		_, ok := seen[inputs[i].Slug]
+		if ok || inputs[i].Default == "" {
+			continue
+		}
+
+		seen[inputs[i].Slug] = struct{}{}
+
+		value, err := inputs[i].Convert(inputs[i].Default)
+		if err != nil {
+			return nil, errutil.Invalid(errors.Wrapf(err, "failed to convert default of input=%v", inputs[i].Slug))
+		}
+
+		converted = append(converted, InputValue{Name: inputs[i].Slug, Value: value})
+	}
+
+	return converted, nil
+}
+
+func commandInputConfigs(command domain.CommandConfig) []domain.InputConfig {
+	var inputs []domain.InputConfig
+	for i := range command.Inputs {
+		inputs = append(inputs, command.Inputs[i].Input)
+	}
+
+	return inputs
+}
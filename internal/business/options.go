@@ -0,0 +1,209 @@
+package business
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+type GetInputOptionsRequest struct {
+	Slug   string
+	Inputs []InputValue
+}
+
+type GetInputOptionsResponse struct {
+	errutil.Response
+	Options []string
+}
+
+func (h Handler) GetInputOptions(ctx context.Context, req GetInputOptionsRequest) (GetInputOptionsResponse, error) {
+	input, ok := h.getInputConfig(req.Slug)
+	if !ok {
+		return GetInputOptionsResponse{}, errors.Wrapf(errutil.NotFound(errutil.Nil(), "Input", req.Slug), "failed to find input slug=%v", req.Slug)
+	}
+
+	userID := UserID(ctx)
+	if userID != "" && !h.isInputAllowed(ctx, req.Slug) {
+		return GetInputOptionsResponse{}, errutil.Unauthorized(errors.Errorf("user is not allowed to use input user=%v input=%v", userID, req.Slug))
+	}
+
+	if input.OptionsCommandSlug == "" {
+		return GetInputOptionsResponse{}, errutil.Invalid(errors.Errorf("input has no options input=%v", req.Slug))
+	}
+
+	options, err := h.getInputOptions(ctx, input, req.Inputs)
+	if err != nil {
+		return GetInputOptionsResponse{}, errors.Wrapf(err, "failed to get options of input slug=%v", req.Slug)
+	}
+
+	return GetInputOptionsResponse{Options: options}, nil
+}
+
+// getInputConfig returns the input config of the first command that declares the input
+func (h Handler) getInputConfig(slug string) (domain.InputConfig, bool) {
+	for _, command := range h.opts.Repository.GetCommandConfigs() {
+		for i := range command.Inputs {
+			if command.Inputs[i].Input.Slug == slug {
+				return command.Inputs[i].Input, true
+			}
+		}
+	}
+
+	return domain.InputConfig{}, false
+}
+
+// isInputAllowed returns whether the user is allowed to execute any command that declares the input
+func (h Handler) isInputAllowed(ctx context.Context, slug string) bool {
+	for commandSlug := range h.allowedCommands(ctx) {
+		command, ok := h.opts.Repository.GetCommandConfig(commandSlug)
+		if !ok {
+			continue
+		}
+
+		for i := range command.Inputs {
+			if command.Inputs[i].Input.Slug == slug {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// getInputOptions lists the options of an input by running its options command,
+// the values of the inputs the options command declares are passed to it so that options can depend on them
+func (h Handler) getInputOptions(ctx context.Context, input domain.InputConfig, inputs []InputValue) ([]string, error) {
+	command, ok := h.opts.Repository.GetCommandConfig(input.OptionsCommandSlug)
+	if !ok {
+		return nil, errors.Wrapf(errutil.NotFound(errutil.Nil(), "Command", input.OptionsCommandSlug), "failed to find command slug=%v", input.OptionsCommandSlug)
+	}
+
+	var dependencies []InputValue
+	for i := range command.Inputs {
+		for j := range inputs {
+			if inputs[j].Name == command.Inputs[i].Input.Slug {
+				dependencies = append(dependencies, inputs[j])
+			}
+		}
+	}
+
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].Name < dependencies[j].Name
+	})
+
+	key := input.Slug
+	for i := range dependencies {
+		key += fmt.Sprintf("&%v=%v", url.QueryEscape(dependencies[i].Name), url.QueryEscape(dependencies[i].Value))
+	}
+
+	if input.OptionsTTL > 0 {
+		options, ok := h.opts.InputOptionsCache.GetInputOptions(key)
+		if ok {
+			return options, nil
+		}
+	}
+
+	o, err := executeCommandConfig(ctx, command, dependencies, h.getSecrets(commandInputConfigs(command), dependencies))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to execute command slug=%v", command.Slug)
+	}
+
+	if o.ExitCode != 0 {
+		return nil, errutil.Unknown(errors.Errorf("options command exited with exit code=%v stderr=%v", o.ExitCode, o.Stderr))
+	}
+
+	options, err := parseInputOptions(o.Stdout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse output of command slug=%v", command.Slug)
+	}
+
+	if input.OptionsTTL > 0 {
+		h.opts.InputOptionsCache.SetInputOptions(key, options, input.OptionsTTL)
+	}
+
+	return options, nil
+}
+
+// parseInputOptions parses either a JSON array or one option per line
+func parseInputOptions(stdout string) ([]string, error) {
+	trimmed := strings.TrimSpace(stdout)
+	if strings.HasPrefix(trimmed, "[") {
+		var values []interface{}
+		err := json.Unmarshal([]byte(trimmed), &values)
+		if err != nil {
+			return nil, errutil.Unknown(errors.Wrapf(err, "failed to json unmarshal options"))
+		}
+
+		options := []string{}
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, errutil.Unknown(errors.Wrapf(err, "failed to json marshal option"))
+				}
+
+				s = string(b)
+			}
+
+			options = append(options, s)
+		}
+
+		return options, nil
+	}
+
+	options := []string{}
+	for _, line := range strings.Split(stdout, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		options = append(options, line)
+	}
+
+	return options, nil
+}
+
+// validateInputValues validates that values of inputs with options are one of the listed options
+func (h Handler) validateInputValues(ctx context.Context, command domain.CommandConfig, inputs []InputValue) error {
+	for i := range command.Inputs {
+		input := command.Inputs[i].Input
+		if input.OptionsCommandSlug == "" {
+			continue
+		}
+
+		for j := range inputs {
+			if inputs[j].Name != input.Slug {
+				continue
+			}
+
+			options, err := h.getInputOptions(ctx, input, inputs)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get options of input slug=%v", input.Slug)
+			}
+
+			var valid bool
+			for _, option := range options {
+				if option == inputs[j].Value {
+					valid = true
+					break
+				}
+			}
+
+			if !valid {
+				return errutil.Invalid(errors.Errorf("value of input=%v is not one of its options value=%v", input.Slug, inputs[j].Value))
+			}
+		}
+	}
+
+	return nil
+}
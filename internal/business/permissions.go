@@ -0,0 +1,139 @@
+package business
+
+import (
+	"context"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+)
+
+// user returns the config of the user of ctx, the groups of the groups header are merged into the groups of
+// the static config of the user, so that users that aren't configured are granted the roles of their groups
+func (h Handler) user(ctx context.Context) (domain.UserConfig, bool) {
+	userID := UserID(ctx)
+	user, ok := h.opts.Repository.GetUserConfig(userID)
+
+	headerGroups := h.opts.Repository.GetHeaderGroupConfigs(Groups(ctx))
+	if len(headerGroups) == 0 {
+		return user, ok
+	}
+
+	merged := domain.UserConfig{
+		ID:     userID,
+		Groups: append([]domain.GroupConfig{}, user.Groups...),
+	}
+
+	for _, group := range headerGroups {
+		if !hasGroup(merged.Groups, group.Slug) {
+			merged.Groups = append(merged.Groups, group)
+		}
+	}
+
+	return merged, true
+}
+
+func hasGroup(groups []domain.GroupConfig, slug string) bool {
+	for i := range groups {
+		if groups[i].Slug == slug {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedViews returns the slugs of the views the user of ctx is allowed to see, limited to the views of the api token
+func (h Handler) allowedViews(ctx context.Context) map[string]struct{} {
+	allowedViews := h.userAllowedViews(ctx)
+
+	token, ok := APIToken(ctx)
+	if !ok {
+		return allowedViews
+	}
+
+	return intersect(allowedViews, token.ViewSlugs)
+}
+
+// allowedCategories returns the slugs of the categories the user of ctx is allowed to see,
+// an api token is allowed to see the categories of its views
+func (h Handler) allowedCategories(ctx context.Context) map[string]struct{} {
+	_, ok := APIToken(ctx)
+	if !ok {
+		return h.userAllowedCategories(ctx)
+	}
+
+	allowedCategories := map[string]struct{}{}
+	for _, v := range h.opts.Repository.GetViewConfigsIn(h.allowedViews(ctx)) {
+		allowedCategories[v.Category.Slug] = struct{}{}
+	}
+
+	return allowedCategories
+}
+
+// allowedCommands returns the slugs of the commands the user of ctx is allowed to execute,
+// limited to the commands of the api token and the commands its views run
+func (h Handler) allowedCommands(ctx context.Context) map[string]struct{} {
+	allowedCommands := h.userAllowedCommands(ctx)
+
+	token, ok := APIToken(ctx)
+	if !ok {
+		return allowedCommands
+	}
+
+	scopes := append([]string{}, token.CommandSlugs...)
+	for _, v := range h.opts.Repository.GetViewConfigsIn(h.allowedViews(ctx)) {
+		if v.Command.Slug != "" {
+			scopes = append(scopes, v.Command.Slug)
+		}
+
+		for slug := range v.Sequence.CommandSlugs() {
+			scopes = append(scopes, slug)
+		}
+	}
+
+	return intersect(allowedCommands, scopes)
+}
+
+// userAllowedViews returns the slugs of the views the user of ctx is allowed to see, permissions of users
+// without header groups are computed when the config is loaded, otherwise they are computed per request
+func (h Handler) userAllowedViews(ctx context.Context) map[string]struct{} {
+	if len(Groups(ctx)) == 0 {
+		return h.opts.Repository.GetUserAllowedViews()[UserID(ctx)]
+	}
+
+	user, _ := h.user(ctx)
+
+	return user.AllowedViews()
+}
+
+func (h Handler) userAllowedCategories(ctx context.Context) map[string]struct{} {
+	if len(Groups(ctx)) == 0 {
+		return h.opts.Repository.GetUserAllowedCategories()[UserID(ctx)]
+	}
+
+	user, _ := h.user(ctx)
+
+	return user.AllowedCategories()
+}
+
+func (h Handler) userAllowedCommands(ctx context.Context) map[string]struct{} {
+	if len(Groups(ctx)) == 0 {
+		return h.opts.Repository.GetUserAllowedCommands()[UserID(ctx)]
+	}
+
+	user, _ := h.user(ctx)
+
+	return user.AllowedCommands()
+}
+
+// intersect returns the slugs of allowed that are in scopes
+func intersect(allowed map[string]struct{}, scopes []string) map[string]struct{} {
+	intersection := map[string]struct{}{}
+	for _, slug := range scopes {
+		_, ok := allowed[slug]
+		if ok {
+			intersection[slug] = struct{}{}
+		}
+	}
+
+	return intersection
+}
@@ -0,0 +1,17 @@
+package business
+
+import (
+	"github.com/ppwfx/shellpane/internal/domain"
+)
+
+// redact replaces matches of the rules in stdout and stderr and counts the replaced matches
+func redact(rules []domain.RedactRule, o CommandOutput) CommandOutput {
+	for _, rule := range rules {
+		var stdout, stderr int
+		o.Stdout, stdout = rule.Apply(o.Stdout)
+		o.Stderr, stderr = rule.Apply(o.Stderr)
+		o.Redactions += stdout + stderr
+	}
+
+	return o
+}
@@ -0,0 +1,233 @@
+package business
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+// requestRun creates a pending run of a command that requires approval, the command executes once another user approves the run
+func (h Handler) requestRun(ctx context.Context, command domain.CommandConfig, inputs []InputValue) (domain.Run, error) {
+	userID := UserID(ctx)
+	if userID == "" {
+		return domain.Run{}, errutil.Invalid(errors.Errorf("command requires approval but the requesting user is unknown command=%v", command.Slug))
+	}
+
+	var runInputs []domain.RunInput
+	for i := range inputs {
+		runInputs = append(runInputs, domain.RunInput{Name: inputs[i].Name, Value: inputs[i].Value})
+	}
+
+	now := time.Now()
+	run := domain.Run{
+		ID:          uuid.NewString(),
+		CommandSlug: command.Slug,
+		Inputs:      runInputs,
+		Status:      domain.RunStatusPending,
+		RequestedBy: userID,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(command.RequiresApproval.Expiry),
+	}
+
+	err := h.opts.RunRepository.CreateRun(run)
+	if err != nil {
+		return domain.Run{}, errors.Wrapf(err, "failed to create run")
+	}
+
+	return run, nil
+}
+
+type GetRunsRequest struct {
+}
+
+type GetRunsResponse struct {
+	errutil.Response
+	Runs []domain.Run
+}
+
+func (h Handler) GetRuns(ctx context.Context, req GetRunsRequest) (GetRunsResponse, error) {
+	userID := UserID(ctx)
+	allowedCommands := h.allowedCommands(ctx)
+
+	now := time.Now()
+	runs := []domain.Run{}
+	for _, run := range h.opts.RunRepository.GetRuns() {
+		if userID != "" {
+			_, ok := allowedCommands[run.CommandSlug]
+			if !ok {
+				continue
+			}
+		}
+
+		if run.Status == domain.RunStatusPending && now.After(run.ExpiresAt) {
+			run.Status = domain.RunStatusExpired
+		}
+
+		command, _ := h.opts.Repository.GetCommandConfig(run.CommandSlug)
+
+		runs = append(runs, redactRun(command, run))
+	}
+
+	return GetRunsResponse{Runs: runs}, nil
+}
+
+type ApproveRunRequest struct {
+	ID string
+}
+
+type ApproveRunResponse struct {
+	errutil.Response
+	Run domain.Run
+}
+
+func (h Handler) ApproveRun(ctx context.Context, req ApproveRunRequest) (ApproveRunResponse, error) {
+	run, command, err := h.reviewRun(ctx, req.ID, domain.RunStatusApproved)
+	if err != nil {
+		return ApproveRunResponse{}, errors.Wrapf(err, "failed to review run id=%v", req.ID)
+	}
+
+	var inputs []InputValue
+	for i := range run.Inputs {
+		inputs = append(inputs, InputValue{Name: run.Inputs[i].Name, Value: run.Inputs[i].Value})
+	}
+
+	o, err := executeCommandConfig(ctx, command, inputs, h.getSecrets(commandInputConfigs(command), inputs))
+	if err != nil {
+		// the run is approved already, so the failure is recorded on the run instead of leaving it without output
+		_, updateErr := h.opts.RunRepository.UpdateRun(run.ID, func(run domain.Run) (domain.Run, error) {
+			run.Status = domain.RunStatusFailed
+			run.Error = err.Error()
+
+			return run, nil
+		})
+		if updateErr != nil {
+			return ApproveRunResponse{}, errors.Wrapf(updateErr, "failed to store failure of run")
+		}
+
+		return ApproveRunResponse{}, errors.Wrapf(err, "failed to execute command")
+	}
+
+	run, err = h.opts.RunRepository.UpdateRun(run.ID, func(run domain.Run) (domain.Run, error) {
+		run.Output = domain.RunOutput{
+			Stdout:     o.Stdout,
+			Stderr:     o.Stderr,
+			ExitCode:   o.ExitCode,
+			Redactions: o.Redactions,
+		}
+
+		return run, nil
+	})
+	if err != nil {
+		return ApproveRunResponse{}, errors.Wrapf(err, "failed to store output")
+	}
+
+	return ApproveRunResponse{Run: redactRun(command, run)}, nil
+}
+
+type RejectRunRequest struct {
+	ID string
+}
+
+type RejectRunResponse struct {
+	errutil.Response
+	Run domain.Run
+}
+
+func (h Handler) RejectRun(ctx context.Context, req RejectRunRequest) (RejectRunResponse, error) {
+	run, command, err := h.reviewRun(ctx, req.ID, domain.RunStatusRejected)
+	if err != nil {
+		return RejectRunResponse{}, errors.Wrapf(err, "failed to review run id=%v", req.ID)
+	}
+
+	return RejectRunResponse{Run: redactRun(command, run)}, nil
+}
+
+// reviewRun moves a pending run to status on behalf of the user in ctx,
+// the reviewer must be allowed to execute the command, must hold an approver role and must not be the requester
+func (h Handler) reviewRun(ctx context.Context, id string, status string) (domain.Run, domain.CommandConfig, error) {
+	userID := UserID(ctx)
+	if userID == "" {
+		return domain.Run{}, domain.CommandConfig{}, errutil.Invalid(errors.Errorf("reviewing user is unknown"))
+	}
+
+	run, ok := h.opts.RunRepository.GetRun(id)
+	if !ok {
+		return domain.Run{}, domain.CommandConfig{}, errors.Wrapf(errutil.NotFound(errutil.Nil(), "Run", id), "failed to find run id=%v", id)
+	}
+
+	command, ok := h.opts.Repository.GetCommandConfig(run.CommandSlug)
+	if !ok {
+		return domain.Run{}, domain.CommandConfig{}, errors.Wrapf(errutil.NotFound(errutil.Nil(), "Command", run.CommandSlug), "failed to find command slug=%v", run.CommandSlug)
+	}
+
+	if command.RequiresApproval == nil {
+		return domain.Run{}, domain.CommandConfig{}, errutil.Invalid(errors.Errorf("command does not require approval anymore command=%v", command.Slug))
+	}
+
+	_, ok = h.allowedCommands(ctx)[command.Slug]
+	if !ok {
+		return domain.Run{}, domain.CommandConfig{}, errutil.Unauthorized(errors.Errorf("user is not allowed to execute command user=%v command=%v", userID, command.Slug))
+	}
+
+	if !h.isApprover(ctx, *command.RequiresApproval) {
+		return domain.Run{}, domain.CommandConfig{}, errutil.Unauthorized(errors.Errorf("user has no approver role user=%v command=%v", userID, command.Slug))
+	}
+
+	if run.RequestedBy == userID {
+		return domain.Run{}, domain.CommandConfig{}, errutil.Unauthorized(errors.Errorf("user must not review own run user=%v run=%v", userID, id))
+	}
+
+	var expired bool
+	run, err := h.opts.RunRepository.UpdateRun(id, func(run domain.Run) (domain.Run, error) {
+		if run.Status != domain.RunStatusPending {
+			return domain.Run{}, errutil.Invalid(errors.Errorf("run is not pending status=%v", run.Status))
+		}
+
+		now := time.Now()
+		if now.After(run.ExpiresAt) {
+			expired = true
+			run.Status = domain.RunStatusExpired
+
+			return run, nil
+		}
+
+		run.Status = status
+		run.ReviewedBy = userID
+		run.ReviewedAt = now
+
+		return run, nil
+	})
+	if err != nil {
+		return domain.Run{}, domain.CommandConfig{}, errors.Wrapf(err, "failed to update run")
+	}
+
+	if expired {
+		return domain.Run{}, domain.CommandConfig{}, errutil.Invalid(errors.Errorf("run expired at=%v", run.ExpiresAt))
+	}
+
+	return run, command, nil
+}
+
+func (h Handler) isApprover(ctx context.Context, approval domain.ApprovalConfig) bool {
+	user, ok := h.user(ctx)
+	if !ok {
+		return false
+	}
+
+	for _, g := range user.Groups {
+		for _, r := range g.Roles {
+			for _, slug := range approval.RoleSlugs {
+				if r.Slug == slug {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+package business
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+)
+
+const maskedSecret = "***"
+
+// secrets holds the values of configured secrets and of secret inputs,
+// secrets are injected into the commands that reference them and masked in the output of all commands
+type secrets struct {
+	values map[string]string
+	masked []string
+}
+
+func (h Handler) getSecrets(inputConfigs []domain.InputConfig, inputs []InputValue) secrets {
+	s := secrets{values: h.opts.Repository.GetSecretValues()}
+
+	for _, v := range s.values {
+		s.masked = append(s.masked, v)
+	}
+
+	for i := range inputConfigs {
+		if inputConfigs[i].Type != domain.InputTypeSecret {
+			continue
+		}
+
+		for j := range inputs {
+			if inputs[j].Name == inputConfigs[i].Slug {
+				s.masked = append(s.masked, inputs[j].Value)
+			}
+		}
+	}
+
+	// mask longer values first, so that a value that contains another value is masked entirely
+	sort.Slice(s.masked, func(i, j int) bool {
+		return len(s.masked[i]) > len(s.masked[j])
+	})
+
+	return s
+}
+
+func (s secrets) env(command domain.CommandConfig) []InputValue {
+	var env []InputValue
+	for i := range command.Secrets {
+		env = append(env, InputValue{Name: command.Secrets[i].Env, Value: s.values[command.Secrets[i].SecretSlug]})
+	}
+
+	return env
+}
+
+func (s secrets) mask(o CommandOutput) CommandOutput {
+	for _, v := range s.masked {
+		if v == "" {
+			continue
+		}
+
+		o.Redactions += strings.Count(o.Stdout, v) + strings.Count(o.Stderr, v)
+		o.Stdout = strings.ReplaceAll(o.Stdout, v, maskedSecret)
+		o.Stderr = strings.ReplaceAll(o.Stderr, v, maskedSecret)
+	}
+
+	return o
+}
+
+// executeCommandConfig executes the command with the secrets it references injected,
+// then masks secrets and applies the redact rules of the command to its output
+func executeCommandConfig(ctx context.Context, command domain.CommandConfig, inputs []InputValue, s secrets) (CommandOutput, error) {
+	env := append(append([]InputValue{}, inputs...), s.env(command)...)
+
+	o, err := executeCommand(ctx, command.Command, env)
+	if err != nil {
+		return CommandOutput{}, errors.Wrapf(err, "failed to execute command")
+	}
+
+	o = s.mask(o)
+
+	return redact(command.RedactRules, o), nil
+}
+
+// redactRun masks the values of secret inputs of a run
+func redactRun(command domain.CommandConfig, run domain.Run) domain.Run {
+	inputs := make([]domain.RunInput, len(run.Inputs))
+	copy(inputs, run.Inputs)
+
+	for i := range inputs {
+		for j := range command.Inputs {
+			if command.Inputs[j].Input.Slug == inputs[i].Name && command.Inputs[j].Input.Type == domain.InputTypeSecret {
+				inputs[i].Value = maskedSecret
+			}
+		}
+	}
+
+	run.Inputs = inputs
+
+	return run
+}
@@ -0,0 +1,742 @@
+package business
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+	"github.com/ppwfx/shellpane/internal/utils/jsonpathutil"
+)
+
+const (
+	SequenceStatusSucceeded = "succeeded"
+	SequenceStatusFailed    = "failed"
+
+	StepStatusSucceeded = "succeeded"
+	StepStatusFailed    = "failed"
+	StepStatusSkipped   = "skipped"
+	StepStatusNotRun    = "not run"
+)
+
+type ExecuteSequenceRequest struct {
+	Slug   string
+	Inputs []InputValue
+}
+
+type ExecuteSequenceResponse struct {
+	errutil.Response
+	Result SequenceResult
+}
+
+type SequenceResult struct {
+	Status    string
+	Steps     []StepResult
+	OnFailure []StepResult
+	Finally   []StepResult
+}
+
+type StepResult struct {
+	Slug      string
+	Name      string
+	Needs     []string
+	Status    string
+	Attempts  int
+	Output    CommandOutput
+	Outputs   map[string]string
+	Error     string
+	StartedAt time.Time
+	Duration  time.Duration
+	Items     []StepItemResult
+	Sequence  *SequenceResult
+}
+
+type StepItemResult struct {
+	Value    string
+	Status   string
+	Attempts int
+	Output   CommandOutput
+	Outputs  map[string]string
+	Error    string
+}
+
+func (h Handler) ExecuteSequence(ctx context.Context, req ExecuteSequenceRequest) (ExecuteSequenceResponse, error) {
+	sequence, ok := h.opts.Repository.GetSequenceConfig(req.Slug)
+	if !ok {
+		return ExecuteSequenceResponse{}, errors.Wrapf(errutil.NotFound(errutil.Nil(), "Sequence", req.Slug), "failed to find sequence slug=%v", req.Slug)
+	}
+
+	userID := UserID(ctx)
+	if userID != "" {
+		allowedCommands := h.allowedCommands(ctx)
+
+		for slug := range sequence.CommandSlugs() {
+			_, ok := allowedCommands[slug]
+			if !ok {
+				return ExecuteSequenceResponse{}, errutil.Unauthorized(errors.Errorf("user is not allowed to execute command user=%v sequence=%v command=%v", userID, req.Slug, slug))
+			}
+		}
+	}
+
+	var inputConfigs []domain.InputConfig
+	for slug := range sequence.CommandSlugs() {
+		command, _ := h.opts.Repository.GetCommandConfig(slug)
+		if command.RequiresApproval != nil {
+			return ExecuteSequenceResponse{}, errutil.Invalid(errors.Errorf("command=%v of sequence=%v requires approval", slug, req.Slug))
+		}
+
+		inputConfigs = append(inputConfigs, commandInputConfigs(command)...)
+	}
+
+	inputs, err := convertInputValues(inputConfigs, req.Inputs)
+	if err != nil {
+		return ExecuteSequenceResponse{}, errors.Wrapf(err, "failed to convert input values")
+	}
+
+	// steps may map inputs from other inputs and outputs, so the inputs are checked again per step
+	check := func(commandSlug string, inputs []InputValue) error {
+		command, _ := h.opts.Repository.GetCommandConfig(commandSlug)
+
+		err := h.validateInputValues(ctx, command, inputs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to validate input values of command=%v", commandSlug)
+		}
+
+		if userID != "" {
+			err = h.checkInputConstraints(ctx, nil, commandSlug, inputs)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check input constraints of command=%v", commandSlug)
+			}
+		}
+
+		return nil
+	}
+
+	for slug := range sequence.CommandSlugs() {
+		err = check(slug, inputs)
+		if err != nil {
+			return ExecuteSequenceResponse{}, errors.Wrapf(err, "failed to check inputs of sequence=%v", req.Slug)
+		}
+	}
+
+	result, err := runSequence(ctx, sequence, inputs, h.getSecrets(inputConfigs, inputs), check)
+	if err != nil {
+		return ExecuteSequenceResponse{}, errors.Wrapf(err, "failed to run sequence slug=%v", req.Slug)
+	}
+
+	return ExecuteSequenceResponse{Result: result}, nil
+}
+
+// inputsCheck fails if the command can't be executed with the inputs, e.g. because a value
+// isn't one of the options of its input or the user isn't allowed to use it
+type inputsCheck func(commandSlug string, inputs []InputValue) error
+
+// sequenceRun holds the state that steps of a sequence run share, steps may run concurrently
+type sequenceRun struct {
+	mu        sync.Mutex
+	inputs    []InputValue
+	exitCodes map[string]int
+	outputs   map[string]map[string]string
+	exported  []exportedOutputs
+	secrets   secrets
+	check     inputsCheck
+}
+
+// exportedOutputs are the outputs a step exported as inputs of the steps that need it
+type exportedOutputs struct {
+	step   *domain.StepConfig
+	values []InputValue
+}
+
+func newSequenceRun(inputs []InputValue, s secrets, check inputsCheck) *sequenceRun {
+	return &sequenceRun{
+		inputs:    inputs,
+		secrets:   s,
+		check:     check,
+		exitCodes: map[string]int{},
+		outputs:   map[string]map[string]string{},
+	}
+}
+
+// stepInputs returns the request inputs, followed by the outputs of the upstream steps, or of all
+// earlier steps if upstream is nil, and the inputs the step maps from specific outputs or other inputs,
+// later values take precedence
+func (r *sequenceRun) stepInputs(step *domain.StepConfig, upstream map[*domain.StepConfig]struct{}) []InputValue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var inputs []InputValue
+	inputs = append(inputs, r.inputs...)
+	for _, e := range r.exported {
+		_, ok := upstream[e.step]
+		if upstream == nil || ok {
+			inputs = append(inputs, e.values...)
+		}
+	}
+
+	values := map[string]string{}
+	for _, i := range inputs {
+		values[i.Name] = i.Value
+	}
+
+	for _, i := range step.Inputs {
+		value := r.outputs[i.StepSlug][i.OutputName]
+		if i.FromInput != "" {
+			value = values[i.FromInput]
+		}
+
+		inputs = append(inputs, InputValue{
+			Name:  i.InputSlug,
+			Value: value,
+		})
+	}
+
+	return inputs
+}
+
+func (r *sequenceRun) isConditionMet(conditions []domain.StepConditionConfig) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return isStepConditionMet(conditions, r.exitCodes)
+}
+
+func (r *sequenceRun) record(step *domain.StepConfig, result StepResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := exportedOutputs{step: step}
+	for _, o := range step.Outputs {
+		value, ok := result.Outputs[o.Name]
+		if !ok {
+			continue
+		}
+
+		e.values = append(e.values, InputValue{Name: o.Name, Value: value})
+	}
+	r.exported = append(r.exported, e)
+
+	if step.Slug == "" {
+		return
+	}
+
+	r.exitCodes[step.Slug] = result.Output.ExitCode
+	r.outputs[step.Slug] = result.Outputs
+}
+
+func runSequence(ctx context.Context, sequence domain.SequenceConfig, inputs []InputValue, s secrets, check inputsCheck) (SequenceResult, error) {
+	result := SequenceResult{Status: SequenceStatusSucceeded}
+	run := newSequenceRun(inputs, s, check)
+
+	steps, aborted, err := runSteps(ctx, sequence.Steps, run)
+	if err != nil {
+		return SequenceResult{}, errors.Wrapf(err, "failed to run steps")
+	}
+	result.Steps = steps
+
+	if aborted {
+		result.Status = SequenceStatusFailed
+	}
+
+	var onFailure, finally []StepResult
+	var failed bool
+	if aborted {
+		onFailure, failed, err = runCleanupSteps(ctx, sequence.OnFailure, run)
+		if err != nil {
+			return SequenceResult{}, errors.Wrapf(err, "failed to run onFailure steps")
+		}
+		result.OnFailure = onFailure
+
+		if failed {
+			result.Status = SequenceStatusFailed
+		}
+	}
+
+	finally, failed, err = runCleanupSteps(ctx, sequence.Finally, run)
+	if err != nil {
+		return SequenceResult{}, errors.Wrapf(err, "failed to run finally steps")
+	}
+	result.Finally = finally
+
+	if failed {
+		result.Status = SequenceStatusFailed
+	}
+
+	return result, nil
+}
+
+// stepDependencies returns the indices of the steps each step waits for. Steps run in order,
+// unless any step declares needs, then steps run as soon as the steps they need finished.
+func stepDependencies(steps []domain.StepConfig) [][]int {
+	indices := map[string]int{}
+	isGraph := false
+	for i := range steps {
+		if steps[i].Slug != "" {
+			indices[steps[i].Slug] = i
+		}
+
+		if len(steps[i].Needs) != 0 {
+			isGraph = true
+		}
+	}
+
+	dependencies := make([][]int, len(steps))
+	for i := range steps {
+		switch {
+		case !isGraph && i > 0:
+			dependencies[i] = []int{i - 1}
+		case isGraph:
+			for _, need := range steps[i].Needs {
+				dependencies[i] = append(dependencies[i], indices[need])
+			}
+		}
+	}
+
+	return dependencies
+}
+
+// upstreamStepSets returns for each step the steps it depends on transitively, so that a step only
+// sees outputs of steps that are guaranteed to have finished before it, independent of scheduling
+func upstreamStepSets(steps []domain.StepConfig, dependencies [][]int) []map[*domain.StepConfig]struct{} {
+	upstream := make([]map[*domain.StepConfig]struct{}, len(steps))
+
+	var collect func(i int) map[*domain.StepConfig]struct{}
+	collect = func(i int) map[*domain.StepConfig]struct{} {
+		if upstream[i] != nil {
+			return upstream[i]
+		}
+
+		upstream[i] = map[*domain.StepConfig]struct{}{}
+		for _, d := range dependencies[i] {
+			upstream[i][&steps[d]] = struct{}{}
+			for step := range collect(d) {
+				upstream[i][step] = struct{}{}
+			}
+		}
+
+		return upstream[i]
+	}
+
+	for i := range steps {
+		collect(i)
+	}
+
+	return upstream
+}
+
+// runSteps runs each step once the steps it depends on finished, independent steps run in parallel.
+// After a step failed without continueOnError no further steps are started and the sequence is aborted.
+func runSteps(ctx context.Context, steps []domain.StepConfig, run *sequenceRun) ([]StepResult, bool, error) {
+	const (
+		statePending = iota
+		stateRunning
+		stateDone
+	)
+
+	type completion struct {
+		index  int
+		result StepResult
+		err    error
+	}
+
+	dependencies := stepDependencies(steps)
+	upstream := upstreamStepSets(steps, dependencies)
+	states := make([]int, len(steps))
+	results := make([]StepResult, len(steps))
+	completions := make(chan completion)
+
+	var running int
+	var aborted bool
+	var err error
+	for {
+		for i := range steps {
+			if aborted || err != nil || states[i] != statePending {
+				continue
+			}
+
+			ready := true
+			for _, d := range dependencies[i] {
+				if states[d] != stateDone {
+					ready = false
+				}
+			}
+
+			if !ready {
+				continue
+			}
+
+			states[i] = stateRunning
+			running++
+
+			go func(i int) {
+				result, err := runStep(ctx, &steps[i], upstream[i], run)
+				completions <- completion{index: i, result: result, err: err}
+			}(i)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		c := <-completions
+		running--
+		states[c.index] = stateDone
+
+		switch {
+		case c.err != nil && err == nil:
+			err = errors.Wrapf(c.err, "failed to run step name=%v", steps[c.index].Name)
+		case c.err != nil:
+		default:
+			results[c.index] = c.result
+
+			if c.result.Status == StepStatusFailed && !steps[c.index].ContinueOnError {
+				aborted = true
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	for i := range steps {
+		if states[i] == statePending {
+			results[i] = StepResult{Slug: steps[i].Slug, Name: steps[i].Name, Needs: steps[i].Needs, Status: StepStatusNotRun}
+		}
+	}
+
+	return results, aborted, nil
+}
+
+// runCleanupSteps runs every step regardless of earlier failures, as cleanup should go as far as possible,
+// cleanup steps see the outputs of all steps that ran before them
+func runCleanupSteps(ctx context.Context, steps []domain.StepConfig, run *sequenceRun) ([]StepResult, bool, error) {
+	var results []StepResult
+	var failed bool
+	for i := range steps {
+		step := &steps[i]
+		stepResult, err := runStep(ctx, step, nil, run)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to run step name=%v", step.Name)
+		}
+		results = append(results, stepResult)
+
+		if stepResult.Status == StepStatusFailed && !step.ContinueOnError {
+			failed = true
+		}
+	}
+
+	return results, failed, nil
+}
+
+// runStep runs the step if its conditions are met, once, for each item of its foreach list
+// or as an included sequence, and records its exit code and outputs in the run
+func runStep(ctx context.Context, step *domain.StepConfig, upstream map[*domain.StepConfig]struct{}, run *sequenceRun) (StepResult, error) {
+	result := StepResult{Slug: step.Slug, Name: step.Name, Needs: step.Needs, StartedAt: time.Now()}
+
+	if !run.isConditionMet(step.When) {
+		result.Status = StepStatusSkipped
+
+		return result, nil
+	}
+
+	inputs := run.stepInputs(step, upstream)
+
+	var err error
+	switch {
+	case step.Sequence.Slug != "":
+		sequenceResult, err := runSequence(ctx, step.Sequence, inputs, run.secrets, run.check)
+		if err != nil {
+			return StepResult{}, errors.Wrapf(err, "failed to run sequence slug=%v", step.Sequence.Slug)
+		}
+
+		result.Sequence = &sequenceResult
+		result.Attempts = 1
+		result.Status = StepStatusSucceeded
+		if sequenceResult.Status == SequenceStatusFailed {
+			result.Status = StepStatusFailed
+			result.Output.ExitCode = 1
+		}
+	case step.Foreach != nil:
+		result, err = runForeachStep(ctx, *step, inputs, run.secrets, run.check, result)
+		if err != nil {
+			return StepResult{}, errors.Wrapf(err, "failed to run foreach step")
+		}
+	default:
+		err = run.check(step.Command.Slug, inputs)
+		if err != nil {
+			// a denied step fails like a failed command, so that the sequence aborts and cleanup steps run
+			result.Status = StepStatusFailed
+			result.Output.ExitCode = 1
+			result.Error = errors.Wrapf(err, "failed to check inputs").Error()
+
+			break
+		}
+
+		result.Attempts, result.Output, err = runStepCommand(ctx, *step, inputs, run.secrets)
+		if err != nil {
+			return StepResult{}, errors.Wrapf(err, "failed to run step command")
+		}
+
+		result.Status = StepStatusSucceeded
+		if result.Output.ExitCode != 0 {
+			result.Status = StepStatusFailed
+		}
+
+		if result.Status == StepStatusSucceeded && len(step.Outputs) != 0 {
+			result.Outputs, err = extractStepOutputs(step.Outputs, result.Output.Stdout)
+			if err != nil {
+				result.Status = StepStatusFailed
+				result.Error = errors.Wrapf(err, "failed to extract outputs").Error()
+			}
+		}
+	}
+
+	result.Duration = time.Since(result.StartedAt)
+
+	run.record(step, result)
+
+	return result, nil
+}
+
+// runStepCommand runs the command of the step and retries it until it succeeds or runs out of retries
+func runStepCommand(ctx context.Context, step domain.StepConfig, inputs []InputValue, s secrets) (int, CommandOutput, error) {
+	var attempts int
+	var o CommandOutput
+
+	backoff := step.Backoff
+	for attempt := 0; attempt <= step.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, CommandOutput{}, errors.Wrapf(errutil.Unknown(ctx.Err()), "failed to wait for retry")
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+		}
+
+		var err error
+		o, err = executeCommandConfig(ctx, step.Command, inputs, s)
+		if err != nil {
+			return 0, CommandOutput{}, errors.Wrapf(err, "failed to execute command slug=%v", step.Command.Slug)
+		}
+
+		attempts++
+
+		if o.ExitCode == 0 {
+			break
+		}
+	}
+
+	return attempts, o, nil
+}
+
+// defaultForeachMaxParallel limits how many items of a parallel foreach step run at once
+const defaultForeachMaxParallel = 8
+
+// runForeachStep runs the step command once per item, the step fails if any item fails.
+// The output of the step concatenates the item outputs and outputs are extracted
+// from each item and joined by new lines.
+func runForeachStep(ctx context.Context, step domain.StepConfig, inputs []InputValue, s secrets, check inputsCheck, result StepResult) (StepResult, error) {
+	values := step.Foreach.Values
+	if step.Foreach.InputSlug != "" {
+		for _, i := range inputs {
+			if i.Name == step.Foreach.InputSlug {
+				values = splitListValue(i.Value)
+			}
+		}
+	}
+
+	itemInputs := func(i int) []InputValue {
+		return append(append([]InputValue{}, inputs...), InputValue{Name: step.Foreach.As, Value: values[i]})
+	}
+
+	// the item value may be a constrained input, so every item is checked before any runs
+	for i := range values {
+		err := check(step.Command.Slug, itemInputs(i))
+		if err != nil {
+			result.Status = StepStatusFailed
+			result.Output.ExitCode = 1
+			result.Error = errors.Wrapf(err, "failed to check inputs of item value=%v", values[i]).Error()
+
+			return result, nil
+		}
+	}
+
+	result.Items = make([]StepItemResult, len(values))
+	errs := make([]error, len(values))
+
+	runItem := func(i int) {
+		attempts, o, err := runStepCommand(ctx, step, itemInputs(i), s)
+		if err != nil {
+			errs[i] = errors.Wrapf(err, "failed to run item value=%v", values[i])
+			return
+		}
+
+		item := StepItemResult{Value: values[i], Status: StepStatusSucceeded, Attempts: attempts, Output: o}
+		if o.ExitCode != 0 {
+			item.Status = StepStatusFailed
+		}
+
+		if item.Status == StepStatusSucceeded && len(step.Outputs) != 0 {
+			item.Outputs, err = extractStepOutputs(step.Outputs, o.Stdout)
+			if err != nil {
+				item.Status = StepStatusFailed
+				item.Error = errors.Wrapf(err, "failed to extract outputs").Error()
+			}
+		}
+
+		result.Items[i] = item
+	}
+
+	switch {
+	case step.Foreach.Parallel:
+		maxParallel := step.Foreach.MaxParallel
+		if maxParallel == 0 {
+			maxParallel = defaultForeachMaxParallel
+		}
+
+		// a bounded number of workers, so that long lists don't start a process per item at once
+		indices := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < maxParallel && w < len(values); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range indices {
+					runItem(i)
+				}
+			}()
+		}
+
+		for i := range values {
+			indices <- i
+		}
+		close(indices)
+		wg.Wait()
+	default:
+		for i := range values {
+			runItem(i)
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return StepResult{}, err
+		}
+	}
+
+	result.Status = StepStatusSucceeded
+	var stdout, stderr strings.Builder
+	for _, item := range result.Items {
+		result.Attempts += item.Attempts
+		stdout.WriteString(item.Output.Stdout)
+		stderr.WriteString(item.Output.Stderr)
+		result.Output.Redactions += item.Output.Redactions
+
+		if item.Status == StepStatusFailed {
+			result.Status = StepStatusFailed
+		}
+
+		if result.Output.ExitCode == 0 {
+			result.Output.ExitCode = item.Output.ExitCode
+		}
+	}
+	result.Output.Stdout = stdout.String()
+	result.Output.Stderr = stderr.String()
+
+	if result.Status == StepStatusSucceeded && len(step.Outputs) != 0 {
+		result.Outputs = map[string]string{}
+		for _, o := range step.Outputs {
+			var itemValues []string
+			for _, item := range result.Items {
+				itemValues = append(itemValues, item.Outputs[o.Name])
+			}
+
+			result.Outputs[o.Name] = strings.Join(itemValues, "\n")
+		}
+	}
+
+	return result, nil
+}
+
+// splitListValue splits a list valued input by new lines and commas
+func splitListValue(value string) []string {
+	var values []string
+	for _, v := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == ',' }) {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func extractStepOutputs(outputs []domain.StepOutputConfig, stdout string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, o := range outputs {
+		switch {
+		case o.Regex != "":
+			r, err := regexp.Compile(o.Regex)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to compile regex=%v", o.Regex)
+			}
+
+			match := r.FindStringSubmatch(stdout)
+			switch {
+			case match == nil:
+				return nil, errors.Errorf("regex=%v of output=%v does not match", o.Regex, o.Name)
+			case len(match) > 1:
+				values[o.Name] = match[1]
+			default:
+				values[o.Name] = match[0]
+			}
+		case o.JSONPath != "":
+			p, err := jsonpathutil.Parse(o.JSONPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse jsonPath=%v", o.JSONPath)
+			}
+
+			value, err := p.Lookup([]byte(stdout))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to look up jsonPath=%v of output=%v", o.JSONPath, o.Name)
+			}
+
+			values[o.Name] = value
+		default:
+			values[o.Name] = strings.TrimSuffix(stdout, "\n")
+		}
+	}
+
+	return values, nil
+}
+
+func isStepConditionMet(conditions []domain.StepConditionConfig, exitCodes map[string]int) bool {
+	for _, c := range conditions {
+		exitCode, ran := exitCodes[c.StepSlug]
+		if !ran {
+			return false
+		}
+
+		var matches bool
+		for _, e := range c.ExitCodes {
+			if e == exitCode {
+				matches = true
+				break
+			}
+		}
+
+		if !matches {
+			return false
+		}
+	}
+
+	return true
+}
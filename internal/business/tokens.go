@@ -0,0 +1,108 @@
+package business
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+	"github.com/ppwfx/shellpane/internal/utils/logutil"
+)
+
+// APITokenPrefix distinguishes api tokens from other bearer tokens, e.g. json web tokens
+const APITokenPrefix = "shellpane_"
+
+// NewAPIToken returns a random api token
+func NewAPIToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read random bytes")
+	}
+
+	return APITokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthenticateAPIToken returns ctx with the owner of the token as user and the token,
+// that limits the permissions of the owner to the scopes of the token
+func (h Handler) AuthenticateAPIToken(ctx context.Context, token string) (context.Context, error) {
+	t, ok := h.opts.Repository.GetAPIToken(domain.HashAPIToken(token))
+	if !ok {
+		return nil, errutil.Unauthorized(errors.New("unknown api token"))
+	}
+
+	if time.Now().After(t.ExpiresAt) {
+		return nil, errutil.Unauthorized(errors.Errorf("api token=%v expired at=%v", t.Slug, t.ExpiresAt))
+	}
+
+	ctx = WithUserID(ctx, t.Owner)
+	ctx = WithAPIToken(ctx, t)
+	ctx = logutil.WithLoggerValue(ctx, logutil.MustLoggerValue(ctx).With("apiToken", t.Slug))
+
+	return ctx, nil
+}
+
+type CreateAPITokenRequest struct {
+	Slug string
+	// Owner is the id of the user whose permissions the token is limited to
+	Owner     string
+	ExpiresAt time.Time
+	Commands  []string
+	Views     []string
+	// Revision is the revision of the config the token was created for
+	Revision string
+}
+
+type CreateAPITokenResponse struct {
+	errutil.Response
+	// Token is only returned once, the config only contains its hash
+	Token    string
+	Revision string
+}
+
+// apiTokenEntity is a token as it is written in the config
+type apiTokenEntity struct {
+	Slug      string   `json:"slug"`
+	Owner     string   `json:"owner"`
+	Hash      string   `json:"hash"`
+	ExpiresAt string   `json:"expiresAt"`
+	Commands  []string `json:"commands,omitempty"`
+	Views     []string `json:"views,omitempty"`
+}
+
+// CreateAPIToken mints a token and adds its hash to the config
+func (h Handler) CreateAPIToken(ctx context.Context, req CreateAPITokenRequest) (CreateAPITokenResponse, error) {
+	err := h.authorizeAdmin(ctx)
+	if err != nil {
+		return CreateAPITokenResponse{}, errors.Wrapf(err, "failed to authorize admin")
+	}
+
+	token, err := NewAPIToken()
+	if err != nil {
+		return CreateAPITokenResponse{}, errors.Wrapf(err, "failed to create api token")
+	}
+
+	entity, err := json.Marshal(apiTokenEntity{
+		Slug:      req.Slug,
+		Owner:     req.Owner,
+		Hash:      domain.HashAPIToken(token),
+		ExpiresAt: req.ExpiresAt.UTC().Format(time.RFC3339),
+		Commands:  req.Commands,
+		Views:     req.Views,
+	})
+	if err != nil {
+		return CreateAPITokenResponse{}, errors.Wrapf(err, "failed to json marshal token")
+	}
+
+	revision, err := h.opts.ConfigAdmin.CreateConfigEntity(ctx, "tokens", entity, req.Revision)
+	if err != nil {
+		return CreateAPITokenResponse{}, errors.Wrapf(err, "failed to create token slug=%v", req.Slug)
+	}
+
+	return CreateAPITokenResponse{Token: token, Revision: revision}, nil
+}
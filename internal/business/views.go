@@ -0,0 +1,107 @@
+package business
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+// applyViewInputs sets the preset values of the view for missing inputs and
+// fails if a value of a locked input differs from the preset value
+func applyViewInputs(view domain.ViewConfig, command domain.CommandConfig, values []InputValue) ([]InputValue, error) {
+	inputsM := map[string]domain.InputConfig{}
+	for _, input := range commandInputConfigs(command) {
+		inputsM[input.Slug] = input
+	}
+
+	applied := append([]InputValue{}, values...)
+	for _, preset := range view.Inputs {
+		i := -1
+		for j := range applied {
+			if applied[j].Name == preset.InputSlug {
+				i = j
+				break
+			}
+		}
+
+		switch {
+		case i == -1:
+			applied = append(applied, InputValue{Name: preset.InputSlug, Value: preset.Value})
+		case preset.Locked:
+			input := inputsM[preset.InputSlug]
+
+			value, err := input.Convert(applied[i].Value)
+			presetValue, _ := input.Convert(preset.Value)
+			if err != nil || value != presetValue {
+				return nil, errutil.Invalid(errors.Errorf("input=%v is locked by view=%v", preset.InputSlug, view.Slug))
+			}
+
+			applied[i].Value = preset.Value
+		}
+	}
+
+	return applied, nil
+}
+
+// isCommandUnlocked returns whether the user may execute the command with any input values,
+// because a view that doesn't lock inputs of the command or a sequence that runs the command is allowed,
+// the views of the owner of an api token count, as the token is limited to commands by allowedCommands
+func (h Handler) isCommandUnlocked(ctx context.Context, slug string) bool {
+	views := h.opts.Repository.GetViewConfigsIn(h.userAllowedViews(ctx))
+	for i := range views {
+		if views[i].Command.Slug == slug && !views[i].HasLockedInputs() {
+			return true
+		}
+
+		_, ok := views[i].Sequence.CommandSlugs()[slug]
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkInputConstraints fails unless one of the grants of a view the user executes the command through
+// allows the input values, i.e. all its constraints hold. Without view the grants of the allowed views
+// that run the command directly, without locking inputs, or through a sequence apply, as for isCommandUnlocked.
+func (h Handler) checkInputConstraints(ctx context.Context, view *domain.ViewConfig, commandSlug string, inputs []InputValue) error {
+	user, _ := h.user(ctx)
+
+	var grants [][]domain.InputConstraint
+	if view != nil {
+		grants = user.ViewGrants(view.Slug)
+	} else {
+		views := h.opts.Repository.GetViewConfigsIn(h.userAllowedViews(ctx))
+		for i := range views {
+			_, ok := views[i].Sequence.CommandSlugs()[commandSlug]
+			if ok || views[i].Command.Slug == commandSlug && !views[i].HasLockedInputs() {
+				grants = append(grants, user.ViewGrants(views[i].Slug)...)
+			}
+		}
+	}
+
+	values := map[string]string{}
+	for _, i := range inputs {
+		values[i.Name] = i.Value
+	}
+
+	for _, constraints := range grants {
+		holds := true
+		for _, c := range constraints {
+			if !c.Holds(values[c.InputSlug]) {
+				holds = false
+				break
+			}
+		}
+
+		if holds {
+			return nil
+		}
+	}
+
+	return errutil.Unauthorized(errors.Errorf("input values are not allowed user=%v command=%v", UserID(ctx), commandSlug))
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/ppwfx/shellpane/internal/business"
 	"github.com/ppwfx/shellpane/internal/utils/errutil"
@@ -28,6 +29,9 @@ type Client struct {
 	opts         ClientOpts
 	userIDHeader string
 	userID       string
+	groupsHeader string
+	groups       []string
+	bearerToken  string
 }
 
 func NewClient(opts ClientOpts) Client {
@@ -43,6 +47,19 @@ func (c Client) WithUserID(header string, userID string) Client {
 	return c
 }
 
+func (c Client) WithGroups(header string, groups ...string) Client {
+	c.groupsHeader = header
+	c.groups = groups
+
+	return c
+}
+
+func (c Client) WithBearerToken(token string) Client {
+	c.bearerToken = token
+
+	return c
+}
+
 func (c Client) ExecuteCommand(ctx context.Context, req business.ExecuteCommandRequest) (rsp business.ExecuteCommandResponse, err error) {
 	rawURL := c.opts.Config.Host + RouteExecuteCommand
 	URL, err := url.Parse(rawURL)
@@ -50,6 +67,55 @@ func (c Client) ExecuteCommand(ctx context.Context, req business.ExecuteCommandR
 		return business.ExecuteCommandResponse{}, errors.Wrapf(err, "failed to parse url=%v", rawURL)
 	}
 
+	q := URL.Query()
+	q.Set("slug", req.Slug)
+	if req.View != "" {
+		q.Set("view", req.View)
+	}
+	for i := range req.Inputs {
+		q.Set("input_"+req.Inputs[i].Name, req.Inputs[i].Value)
+	}
+
+	URL.RawQuery = q.Encode()
+
+	err = c.doJsonRequest(ctx, URL.String(), http.MethodGet, nil, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", URL.String())
+	}
+
+	return
+}
+
+func (c Client) ExecuteSequence(ctx context.Context, req business.ExecuteSequenceRequest) (rsp business.ExecuteSequenceResponse, err error) {
+	rawURL := c.opts.Config.Host + RouteExecuteSequence
+	URL, err := url.Parse(rawURL)
+	if err != nil {
+		return business.ExecuteSequenceResponse{}, errors.Wrapf(err, "failed to parse url=%v", rawURL)
+	}
+
+	q := URL.Query()
+	q.Set("slug", req.Slug)
+	for i := range req.Inputs {
+		q.Set("input_"+req.Inputs[i].Name, req.Inputs[i].Value)
+	}
+
+	URL.RawQuery = q.Encode()
+
+	err = c.doJsonRequest(ctx, URL.String(), http.MethodGet, nil, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", URL.String())
+	}
+
+	return
+}
+
+func (c Client) GetInputOptions(ctx context.Context, req business.GetInputOptionsRequest) (rsp business.GetInputOptionsResponse, err error) {
+	rawURL := c.opts.Config.Host + RouteGetInputOptions
+	URL, err := url.Parse(rawURL)
+	if err != nil {
+		return business.GetInputOptionsResponse{}, errors.Wrapf(err, "failed to parse url=%v", rawURL)
+	}
+
 	q := URL.Query()
 	q.Set("slug", req.Slug)
 	for i := range req.Inputs {
@@ -66,6 +132,61 @@ func (c Client) ExecuteCommand(ctx context.Context, req business.ExecuteCommandR
 	return
 }
 
+func (c Client) GetRuns(ctx context.Context, req business.GetRunsRequest) (rsp business.GetRunsResponse, err error) {
+	rawURL := c.opts.Config.Host + RouteGetRuns
+	URL, err := url.Parse(rawURL)
+	if err != nil {
+		return business.GetRunsResponse{}, errors.Wrapf(err, "failed to parse url=%v", rawURL)
+	}
+
+	err = c.doJsonRequest(ctx, URL.String(), http.MethodGet, nil, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", URL.String())
+	}
+
+	return
+}
+
+func (c Client) ApproveRun(ctx context.Context, req business.ApproveRunRequest) (rsp business.ApproveRunResponse, err error) {
+	rawURL := c.opts.Config.Host + RouteApproveRun
+	URL, err := url.Parse(rawURL)
+	if err != nil {
+		return business.ApproveRunResponse{}, errors.Wrapf(err, "failed to parse url=%v", rawURL)
+	}
+
+	q := URL.Query()
+	q.Set("id", req.ID)
+
+	URL.RawQuery = q.Encode()
+
+	err = c.doJsonRequest(ctx, URL.String(), http.MethodGet, nil, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", URL.String())
+	}
+
+	return
+}
+
+func (c Client) RejectRun(ctx context.Context, req business.RejectRunRequest) (rsp business.RejectRunResponse, err error) {
+	rawURL := c.opts.Config.Host + RouteRejectRun
+	URL, err := url.Parse(rawURL)
+	if err != nil {
+		return business.RejectRunResponse{}, errors.Wrapf(err, "failed to parse url=%v", rawURL)
+	}
+
+	q := URL.Query()
+	q.Set("id", req.ID)
+
+	URL.RawQuery = q.Encode()
+
+	err = c.doJsonRequest(ctx, URL.String(), http.MethodGet, nil, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", URL.String())
+	}
+
+	return
+}
+
 func (c Client) GetViewConfigs(ctx context.Context, req business.GetViewConfigsRequest) (rsp business.GetViewConfigsResponse, err error) {
 	rawURL := c.opts.Config.Host + RouteGetViewConfigs
 	URL, err := url.Parse(rawURL)
@@ -96,6 +217,69 @@ func (c Client) GetCategoryConfigs(ctx context.Context, req business.GetCategory
 	return
 }
 
+func (c Client) GetConfigEntities(ctx context.Context, req business.GetConfigEntitiesRequest) (rsp business.GetConfigEntitiesResponse, err error) {
+	rawURL := c.opts.Config.Host + RouteGetConfigEntities
+	URL, err := url.Parse(rawURL)
+	if err != nil {
+		return business.GetConfigEntitiesResponse{}, errors.Wrapf(err, "failed to parse url=%v", rawURL)
+	}
+
+	q := URL.Query()
+	q.Set("kind", req.Kind)
+	URL.RawQuery = q.Encode()
+
+	err = c.doJsonRequest(ctx, URL.String(), http.MethodGet, nil, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", URL.String())
+	}
+
+	return
+}
+
+func (c Client) CreateConfigEntity(ctx context.Context, req business.CreateConfigEntityRequest) (rsp business.CreateConfigEntityResponse, err error) {
+	u := c.opts.Config.Host + RouteCreateConfigEntity
+
+	err = c.doJsonRequest(ctx, u, http.MethodPost, req, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", u)
+	}
+
+	return
+}
+
+func (c Client) UpdateConfigEntity(ctx context.Context, req business.UpdateConfigEntityRequest) (rsp business.UpdateConfigEntityResponse, err error) {
+	u := c.opts.Config.Host + RouteUpdateConfigEntity
+
+	err = c.doJsonRequest(ctx, u, http.MethodPost, req, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", u)
+	}
+
+	return
+}
+
+func (c Client) DeleteConfigEntity(ctx context.Context, req business.DeleteConfigEntityRequest) (rsp business.DeleteConfigEntityResponse, err error) {
+	u := c.opts.Config.Host + RouteDeleteConfigEntity
+
+	err = c.doJsonRequest(ctx, u, http.MethodPost, req, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", u)
+	}
+
+	return
+}
+
+func (c Client) CreateAPIToken(ctx context.Context, req business.CreateAPITokenRequest) (rsp business.CreateAPITokenResponse, err error) {
+	u := c.opts.Config.Host + RouteCreateAPIToken
+
+	err = c.doJsonRequest(ctx, u, http.MethodPost, req, &rsp)
+	if err != nil {
+		return rsp, errors.Wrapf(err, "failed to do json request with url=%v", u)
+	}
+
+	return
+}
+
 func (c Client) doJsonRequest(ctx context.Context, u string, method string, req interface{}, rsp interface{}) error {
 	var b bytes.Buffer
 	err := json.NewEncoder(&b).Encode(req)
@@ -108,6 +292,14 @@ func (c Client) doJsonRequest(ctx context.Context, u string, method string, req
 		return errors.Wrapf(err, "failed to create request for url=%v", u)
 	}
 
+	if c.bearerToken != "" {
+		r.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	if c.groupsHeader != "" {
+		r.Header.Set(c.groupsHeader, strings.Join(c.groups, ","))
+	}
+
 	if c.userIDHeader != "" {
 		r.Header.Set(c.userIDHeader, c.userID)
 	}
@@ -4,6 +4,8 @@ type Config struct {
 	HttpAddr      string
 	Listener      string
 	UserIDHeader  string
+	GroupsHeader  string
+	JWT           JWTConfig
 	DefaultUserID string
 	Router        RouterConfig
 	Client        ClientConfig
@@ -4,7 +4,11 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/ppwfx/shellpane/internal/business"
+	"github.com/ppwfx/shellpane/internal/utils/jwtutil"
+	"github.com/ppwfx/shellpane/internal/utils/logutil"
 )
 
 type BasicAuthConfig struct {
@@ -12,6 +16,19 @@ type BasicAuthConfig struct {
 	Password string
 }
 
+type JWTConfig struct {
+	// JWKS is the path or the http(s) url of a json web key set, e.g. the jwks_uri of an oidc provider
+	JWKS string
+	// KeyFile is the path of a pem encoded public key, if no JWKS is set
+	KeyFile  string
+	Issuer   string
+	Audience string
+	// UserIDClaim is the claim that holds the user id, it defaults to sub
+	UserIDClaim string
+	// GroupsClaim is the claim that holds the groups of the user, the groups are not read if it is empty
+	GroupsClaim string
+}
+
 func WithUserIDMiddleware(handler http.Handler, userIDHeader string, defaultUserID string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		headerUserID := r.Header.Get(userIDHeader)
@@ -35,6 +52,95 @@ func WithUserIDMiddleware(handler http.Handler, userIDHeader string, defaultUser
 	}
 }
 
+// WithGroupsMiddleware passes the groups of the user in the groups header to the handler, groups are separated by commas.
+// The header must be set by a trusted proxy that removes the header from the requests of clients.
+func WithGroupsMiddleware(handler http.Handler, groupsHeader string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var groups []string
+		for _, v := range r.Header.Values(groupsHeader) {
+			for _, group := range strings.Split(v, ",") {
+				group = strings.TrimSpace(group)
+				if group != "" {
+					groups = append(groups, group)
+				}
+			}
+		}
+
+		if len(groups) > 0 {
+			r = r.WithContext(business.WithGroups(r.Context(), groups))
+		}
+
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// WithJWTMiddleware authenticates requests with a bearer token and passes the user id and the groups
+// of the claims of the token to the handler
+func WithJWTMiddleware(handler http.Handler, config JWTConfig, verifier jwtutil.Verifier) http.HandlerFunc {
+	userIDClaim := config.UserIDClaim
+	if userIDClaim == "" {
+		userIDClaim = "sub"
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		if token == authorization || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := verifier.Verify(r.Context(), token)
+		if err != nil {
+			logutil.MustLoggerValue(r.Context()).With("error", errors.Wrap(err, "failed to verify bearer token")).Info()
+
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		userID := claims.String(userIDClaim)
+		if userID == "" {
+			logutil.MustLoggerValue(r.Context()).With("error", errors.Errorf("claim=%v is missing", userIDClaim)).Info()
+
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		ctx := business.WithUserID(r.Context(), userID)
+		if config.GroupsClaim != "" {
+			ctx = business.WithGroups(ctx, claims.Strings(config.GroupsClaim))
+		}
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// WithAPITokenMiddleware serves requests with an api token as bearer token with handler on behalf of the owner
+// of the token, other requests are served with authenticated, e.g. handler wrapped with the basic auth or jwt middleware
+func WithAPITokenMiddleware(handler http.Handler, authenticated http.Handler, h business.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !strings.HasPrefix(token, business.APITokenPrefix) {
+			authenticated.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := h.AuthenticateAPIToken(r.Context(), token)
+		if err != nil {
+			logutil.MustLoggerValue(r.Context()).With("error", errors.Wrap(err, "failed to authenticate api token")).Info()
+
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
 func WithBasicAuthMiddleware(next http.Handler, config BasicAuthConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		u, p, ok := r.BasicAuth()
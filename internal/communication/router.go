@@ -1,14 +1,15 @@
 package communication
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"strings"
 
 	"github.com/pkg/errors"
 
 	"github.com/ppwfx/shellpane/internal/business"
-	"github.com/ppwfx/shellpane/internal/domain"
 	"github.com/ppwfx/shellpane/internal/utils/errutil"
 	"github.com/ppwfx/shellpane/internal/utils/logutil"
 )
@@ -18,16 +19,28 @@ type RouterConfig struct {
 }
 
 type RouterOpts struct {
-	Config          RouterConfig
-	Handler         business.Handler
-	CategoryConfigs []domain.CategoryConfig
+	Config  RouterConfig
+	Handler business.Handler
+	// Schema is the json schema of the shellpane config that is served at RouteSchemaJSON
+	Schema []byte
 }
 
 const (
 	RouteExecuteCommand      = "/executeCommand"
+	RouteExecuteSequence     = "/executeSequence"
+	RouteGetInputOptions     = "/getInputOptions"
+	RouteGetRuns             = "/getRuns"
+	RouteApproveRun          = "/approveRun"
+	RouteRejectRun           = "/rejectRun"
 	RouteGetViewConfigs      = "/getViewConfigs"
 	RouteGetCategoryConfigs  = "/getCategoryConfigs"
 	RouteStaticCategoriesCSS = "/static/categories.css"
+	RouteSchemaJSON          = "/schema.json"
+	RouteGetConfigEntities   = "/admin/getConfigEntities"
+	RouteCreateConfigEntity  = "/admin/createConfigEntity"
+	RouteUpdateConfigEntity  = "/admin/updateConfigEntity"
+	RouteDeleteConfigEntity  = "/admin/deleteConfigEntity"
+	RouteCreateAPIToken      = "/admin/createAPIToken"
 	RouteDebugDumpRequest    = "/debug/dumpRequest"
 )
 
@@ -39,18 +52,9 @@ func NewRouter(opts RouterOpts) http.Handler {
 	mux.HandleFunc(RouteExecuteCommand, func(w http.ResponseWriter, r *http.Request) {
 		var req business.ExecuteCommandRequest
 		req.Slug = r.URL.Query().Get("slug")
+		req.View = r.URL.Query().Get("view")
 		req.Format = r.URL.Query().Get("format")
-
-		for k, v := range r.URL.Query() {
-			if !strings.HasPrefix(k, "input_") {
-				continue
-			}
-
-			req.Inputs = append(req.Inputs, business.InputValue{
-				Name:  strings.TrimPrefix(k, "input_"),
-				Value: strings.Join(v, ""),
-			})
-		}
+		req.Inputs = getInputValues(r.URL.Query())
 
 		rsp, err := opts.Handler.ExecuteCommand(r.Context(), req)
 
@@ -64,6 +68,42 @@ func NewRouter(opts RouterOpts) http.Handler {
 		return
 	})
 
+	mux.HandleFunc(RouteExecuteSequence, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.ExecuteSequenceRequest
+		req.Slug = r.URL.Query().Get("slug")
+		req.Inputs = getInputValues(r.URL.Query())
+
+		return opts.Handler.ExecuteSequence(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteGetInputOptions, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.GetInputOptionsRequest
+		req.Slug = r.URL.Query().Get("slug")
+		req.Inputs = getInputValues(r.URL.Query())
+
+		return opts.Handler.GetInputOptions(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteGetRuns, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.GetRunsRequest
+
+		return opts.Handler.GetRuns(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteApproveRun, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.ApproveRunRequest
+		req.ID = r.URL.Query().Get("id")
+
+		return opts.Handler.ApproveRun(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteRejectRun, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.RejectRunRequest
+		req.ID = r.URL.Query().Get("id")
+
+		return opts.Handler.RejectRun(r.Context(), req)
+	}))
+
 	mux.HandleFunc(RouteGetViewConfigs, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
 		var req business.GetViewConfigsRequest
 
@@ -76,6 +116,63 @@ func NewRouter(opts RouterOpts) http.Handler {
 		return opts.Handler.GetCategoryConfigs(r.Context(), req)
 	}))
 
+	mux.HandleFunc(RouteGetConfigEntities, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.GetConfigEntitiesRequest
+		req.Kind = r.URL.Query().Get("kind")
+
+		return opts.Handler.GetConfigEntities(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteCreateConfigEntity, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.CreateConfigEntityRequest
+		err := decodeJSONBody(r, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		return opts.Handler.CreateConfigEntity(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteUpdateConfigEntity, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.UpdateConfigEntityRequest
+		err := decodeJSONBody(r, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		return opts.Handler.UpdateConfigEntity(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteDeleteConfigEntity, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.DeleteConfigEntityRequest
+		err := decodeJSONBody(r, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		return opts.Handler.DeleteConfigEntity(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteCreateAPIToken, errutil.HandlerFuncJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		var req business.CreateAPITokenRequest
+		err := decodeJSONBody(r, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		return opts.Handler.CreateAPIToken(r.Context(), req)
+	}))
+
+	mux.HandleFunc(RouteSchemaJSON, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json")
+
+		_, err := w.Write(opts.Schema)
+		if err != nil {
+			logutil.MustLoggerValue(r.Context()).With("error", errors.Wrap(err, "failed to write to response writer")).Error()
+			return
+		}
+	})
+
 	mux.HandleFunc(RouteDebugDumpRequest, func(w http.ResponseWriter, r *http.Request) {
 		log := logutil.MustLoggerValue(r.Context())
 
@@ -95,7 +192,37 @@ func NewRouter(opts RouterOpts) http.Handler {
 		return
 	})
 
-	mux.HandleFunc(RouteStaticCategoriesCSS, getCategoriesCSSHandler(opts.CategoryConfigs))
+	mux.HandleFunc(RouteStaticCategoriesCSS, getCategoriesCSSHandler(opts.Handler))
 
 	return mux
 }
+
+func getInputValues(query url.Values) []business.InputValue {
+	var inputs []business.InputValue
+	for k, v := range query {
+		if !strings.HasPrefix(k, "input_") {
+			continue
+		}
+
+		inputs = append(inputs, business.InputValue{
+			Name:  strings.TrimPrefix(k, "input_"),
+			Value: strings.Join(v, ""),
+		})
+	}
+
+	return inputs
+}
+
+// decodeJSONBody decodes the json body of a POST request into req
+func decodeJSONBody(r *http.Request, req interface{}) error {
+	if r.Method != http.MethodPost {
+		return errutil.Invalid(errors.Errorf("method=%v not allowed, expected %v", r.Method, http.MethodPost))
+	}
+
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		return errutil.Decoding(errors.Wrapf(err, "failed to json decode request body"))
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/ppwfx/shellpane/internal/business"
 	"github.com/ppwfx/shellpane/internal/domain"
 	"github.com/ppwfx/shellpane/internal/utils/logutil"
 )
@@ -105,12 +106,22 @@ var cssTemplate = template.Must(template.New("").Parse(`
 {{end}}
 `))
 
-func getCategoriesCSSHandler(cs []domain.CategoryConfig) func(w http.ResponseWriter, r *http.Request) {
+// getCategoriesCSSHandler renders the css of the current categories, so that it reflects reloaded configs
+func getCategoriesCSSHandler(h business.Handler) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log := logutil.MustLoggerValue(r.Context())
 
+		rsp, err := h.GetCategoryConfigs(r.Context(), business.GetCategoryConfigsRequest{})
+		if err != nil {
+			log.Errorf("failed to get category configs: %v", err.Error())
+
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
 		var b bytes.Buffer
-		err := cssTemplate.Execute(&b, cs)
+		err = cssTemplate.Execute(&b, domain.FlattenCategories(rsp.CategoryConfigs))
 		if err != nil {
 			log.Errorf("failed to execute template: %v", err.Error())
 
@@ -11,6 +11,11 @@ let c = new client.Client({
     },
 });
 
+// flattenCategories returns the categories and the categories nested in them, parents before their children
+const flattenCategories = (categories: client.CategoryConfig[] | undefined, prefix: string = ""): { category: client.CategoryConfig, path: string }[] => {
+    return (categories || []).flatMap((c) => [{category: c, path: prefix + c.Name}, ...flattenCategories(c.Categories, prefix + c.Name + "/")])
+}
+
 function App() {
     const [getViewConfigsRsp, setViewConfigsRsp] = React.useState<client.GetViewConfigsResponse | undefined>(undefined);
     const [getCategoryConfigsRsp, setCategoryConfigsRsp] = React.useState<client.GetCategoryConfigsResponse | undefined>(undefined);
@@ -41,7 +46,9 @@ function App() {
 
     let viewConfigs:client.ViewConfig[]
     if (category) {
-        viewConfigs = getViewConfigsRsp?.ViewConfigs?.filter((c) => c.Category.Slug == category.Slug) || []
+        // a category shows its views and the views of the categories nested in it
+        const slugs = flattenCategories([category]).map((c) => c.category.Slug)
+        viewConfigs = getViewConfigsRsp?.ViewConfigs?.filter((c) => slugs.includes(c.Category.Slug)) || []
     } else {
         viewConfigs = getViewConfigsRsp?.ViewConfigs || []
     }
@@ -56,8 +63,8 @@ function App() {
             <div className="header">
                 <span className={"header__logo"}>🐚 shellpane{category ? "/":""}{category ? <span className={"header__logo__category"} style={{color:category.Color}}>{category.Name}</span>:null}</span>
                 <span className={"header__categories"}>
-                    {getCategoryConfigsRsp?.CategoryConfigs?.map((c => {
-                        return <a className={"header__category__category" + (c.Slug == category?.Slug ? " header__categories__category--selected": "")} style={{color:c.Color}} onClick={()=>setCategory(c)}> {c.Name}</a>
+                    {flattenCategories(getCategoryConfigsRsp?.CategoryConfigs).map((({category: c, path}) => {
+                        return <a className={"header__category__category" + (c.Slug == category?.Slug ? " header__categories__category--selected": "")} style={{color:c.Color}} onClick={()=>setCategory(c)}> {path}</a>
                     }))}
                 </span>
             </div>
@@ -4,31 +4,102 @@ export const FormatRaw = "raw"
 
 export interface ViewConfig {
     Name: string
+    Description: string
     Command?: CommandConfig
     Sequence?: SequenceConfig
     Category: CategoryConfig
+    RequiresApproval?: ApprovalConfig
+    Inputs?: ViewInputConfig[]
+    Order: number
+    Tags?: string[]
+}
+
+export interface ViewInputConfig {
+    InputSlug: string
+    Value: string
+    Locked: boolean
+}
+
+export interface ApprovalConfig {
+    RoleSlugs: string[]
+    Expiry: number
 }
 
 export interface CategoryConfig {
     Slug: string
     Name: string
     Color: string
+    Parent: string
+    Order: number
+    Categories?: CategoryConfig[]
 }
 
 export interface SequenceConfig {
     Slug: string
     Steps: StepConfig[]
+    OnFailure?: StepConfig[]
+    Finally?: StepConfig[]
 }
 
 export interface StepConfig {
+    Slug: string
     Name: string
     Command: CommandConfig
+    Sequence: SequenceConfig
+    Needs?: string[]
+    Foreach?: StepForeachConfig
+    Retries: number
+    Backoff: number
+    ContinueOnError: boolean
+    When?: StepConditionConfig[]
+    Inputs?: StepInputConfig[]
+    Outputs?: StepOutputConfig[]
+}
+
+export interface StepInputConfig {
+    InputSlug: string
+    StepSlug: string
+    OutputName: string
+    FromInput: string
+}
+
+export interface StepOutputConfig {
+    Name: string
+    Regex: string
+    JSONPath: string
+}
+
+export interface StepForeachConfig {
+    InputSlug: string
+    Values?: string[]
+    As: string
+    Parallel: boolean
+    MaxParallel: number
+}
+
+export interface StepConditionConfig {
+    StepSlug: string
+    ExitCodes: number[]
 }
 
 export interface CommandConfig {
     Slug: string
     Command: string
+    Description: string
     Inputs: CommandInputConfig[]
+    Secrets?: CommandSecretConfig[]
+    RedactRules?: RedactRule[]
+    RequiresApproval?: ApprovalConfig
+}
+
+export interface RedactRule {
+    Regex: string
+    Replacement: string
+}
+
+export interface CommandSecretConfig {
+    SecretSlug: string
+    Env: string
 }
 
 export interface CommandInputConfig {
@@ -36,8 +107,24 @@ export interface CommandInputConfig {
     Input: InputConfig
 }
 
+export const InputTypeString = "string"
+export const InputTypeInt = "int"
+export const InputTypeBool = "bool"
+export const InputTypeEnum = "enum"
+export const InputTypeMultiline = "multiline"
+export const InputTypeDate = "date"
+export const InputTypeSecret = "secret"
+
 export interface InputConfig {
     Slug: string
+    Type: string
+    Default: string
+    Label: string
+    Description: string
+    Placeholder: string
+    Values?: string[]
+    OptionsCommandSlug: string
+    OptionsTTL: number
 }
 
 export interface ResponseError {
@@ -51,6 +138,7 @@ export interface ErrorResponse {
 
 export interface ExecuteCommandRequest {
     Slug: string
+    View?: string
     Inputs: InputValue[]
     Format?: string
 }
@@ -62,12 +150,106 @@ export interface InputValue {
 
 export interface ExecuteCommandResponse extends ErrorResponse {
     Output: CommandOutput
+    Run?: Run
+}
+
+export const RunStatusPending = "pending"
+export const RunStatusApproved = "approved"
+export const RunStatusRejected = "rejected"
+export const RunStatusExpired = "expired"
+export const RunStatusFailed = "failed"
+
+export interface Run {
+    ID: string
+    CommandSlug: string
+    Inputs?: InputValue[]
+    Status: string
+    RequestedBy: string
+    RequestedAt: string
+    ExpiresAt: string
+    ReviewedBy: string
+    ReviewedAt: string
+    Output: CommandOutput
+    Error?: string
+}
+
+export interface GetInputOptionsRequest {
+    Slug: string
+    Inputs: InputValue[]
+}
+
+export interface GetInputOptionsResponse extends ErrorResponse {
+    Options?: string[]
+}
+
+export interface GetRunsRequest {
+}
+
+export interface GetRunsResponse extends ErrorResponse {
+    Runs?: Run[]
+}
+
+export interface ApproveRunRequest {
+    ID: string
+}
+
+export interface ApproveRunResponse extends ErrorResponse {
+    Run: Run
+}
+
+export interface RejectRunRequest {
+    ID: string
+}
+
+export interface RejectRunResponse extends ErrorResponse {
+    Run: Run
 }
 
 export interface CommandOutput {
     Stdout: string
     Stderr: string
     ExitCode: number
+    Redactions: number
+}
+
+export interface ExecuteSequenceRequest {
+    Slug: string
+    Inputs: InputValue[]
+}
+
+export interface ExecuteSequenceResponse extends ErrorResponse {
+    Result: SequenceResult
+}
+
+export interface SequenceResult {
+    Status: string
+    Steps: StepResult[]
+    OnFailure?: StepResult[]
+    Finally?: StepResult[]
+}
+
+export interface StepResult {
+    Slug: string
+    Name: string
+    Needs?: string[]
+    Status: string
+    Attempts: number
+    Output: CommandOutput
+    Outputs?: { [name: string]: string }
+    Error: string
+    StartedAt: string
+    Duration: number
+    Items?: StepItemResult[]
+    Sequence?: SequenceResult
+}
+
+export interface StepItemResult {
+    Value: string
+    Status: string
+    Attempts: number
+    Output: CommandOutput
+    Outputs?: { [name: string]: string }
+    Error: string
 }
 
 export interface GetViewConfigsRequest {
@@ -84,6 +266,60 @@ export interface GetCategoryConfigsResponse extends ErrorResponse {
     CategoryConfigs?: CategoryConfig[]
 }
 
+export interface GetConfigEntitiesRequest {
+    Kind: string
+}
+
+export interface GetConfigEntitiesResponse extends ErrorResponse {
+    Revision: string
+    Entities?: any[]
+}
+
+export interface CreateConfigEntityRequest {
+    Kind: string
+    Revision: string
+    Entity: any
+}
+
+export interface CreateConfigEntityResponse extends ErrorResponse {
+    Revision: string
+}
+
+export interface UpdateConfigEntityRequest {
+    Kind: string
+    ID: string
+    Revision: string
+    Entity: any
+}
+
+export interface UpdateConfigEntityResponse extends ErrorResponse {
+    Revision: string
+}
+
+export interface DeleteConfigEntityRequest {
+    Kind: string
+    ID: string
+    Revision: string
+}
+
+export interface DeleteConfigEntityResponse extends ErrorResponse {
+    Revision: string
+}
+
+export interface CreateAPITokenRequest {
+    Slug: string
+    Owner: string
+    ExpiresAt: string
+    Commands?: string[]
+    Views?: string[]
+    Revision: string
+}
+
+export interface CreateAPITokenResponse extends ErrorResponse {
+    Token: string
+    Revision: string
+}
+
 export interface ClientConfig {
     addr: string;
 }
@@ -131,6 +367,9 @@ export class Client {
     ExecuteCommandLink(req: ExecuteCommandRequest): string {
         let url = new URL(this.opts.config.addr + "/executeCommand")
         url.searchParams.append("slug", req.Slug)
+        if (req.View && req.View !== "") {
+            url.searchParams.append("view", req.View)
+        }
         if (req.Format && req.Format !== "") {
             url.searchParams.append("format", req.Format)
         }
@@ -143,6 +382,93 @@ export class Client {
         return url.toString()
     }
 
+    async ExecuteSequence(req: ExecuteSequenceRequest): Promise<ExecuteSequenceResponse> {
+        let url = new URL(this.opts.config.addr + "/executeSequence")
+        url.searchParams.append("slug", req.Slug)
+        if (req.Inputs) {
+            req.Inputs.forEach((v: InputValue) => {
+                url.searchParams.append("input_" + v.Name, v.Value)
+            })
+        }
+
+        let rsp = await this.client.request<ExecuteSequenceResponse>({
+            url: url.toString(),
+            method: "get",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async GetInputOptions(req: GetInputOptionsRequest): Promise<GetInputOptionsResponse> {
+        let url = new URL(this.opts.config.addr + "/getInputOptions")
+        url.searchParams.append("slug", req.Slug)
+        if (req.Inputs) {
+            req.Inputs.forEach((v: InputValue) => {
+                url.searchParams.append("input_" + v.Name, v.Value)
+            })
+        }
+
+        let rsp = await this.client.request<GetInputOptionsResponse>({
+            url: url.toString(),
+            method: "get",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async GetRuns(req: GetRunsRequest): Promise<GetRunsResponse> {
+        let rsp = await this.client.request<GetRunsResponse>({
+            url: this.opts.config.addr + "/getRuns",
+            method: "get",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async ApproveRun(req: ApproveRunRequest): Promise<ApproveRunResponse> {
+        let url = new URL(this.opts.config.addr + "/approveRun")
+        url.searchParams.append("id", req.ID)
+
+        let rsp = await this.client.request<ApproveRunResponse>({
+            url: url.toString(),
+            method: "get",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async RejectRun(req: RejectRunRequest): Promise<RejectRunResponse> {
+        let url = new URL(this.opts.config.addr + "/rejectRun")
+        url.searchParams.append("id", req.ID)
+
+        let rsp = await this.client.request<RejectRunResponse>({
+            url: url.toString(),
+            method: "get",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
     async ExecuteCommand(req: ExecuteCommandRequest): Promise<ExecuteCommandResponse> {
         let rsp = await this.client.request<ExecuteCommandResponse>({
             url: this.ExecuteCommandLink(req),
@@ -155,4 +481,72 @@ export class Client {
 
         return rsp.data
     }
-}
\ No newline at end of file
+
+    async GetConfigEntities(req: GetConfigEntitiesRequest): Promise<GetConfigEntitiesResponse> {
+        let url = new URL(this.opts.config.addr + "/admin/getConfigEntities")
+        url.searchParams.append("kind", req.Kind)
+
+        let rsp = await this.client.request<GetConfigEntitiesResponse>({
+            url: url.toString(),
+            method: "get",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async CreateConfigEntity(req: CreateConfigEntityRequest): Promise<CreateConfigEntityResponse> {
+        let rsp = await this.client.request<CreateConfigEntityResponse>({
+            url: this.opts.config.addr + "/admin/createConfigEntity",
+            method: "post",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async UpdateConfigEntity(req: UpdateConfigEntityRequest): Promise<UpdateConfigEntityResponse> {
+        let rsp = await this.client.request<UpdateConfigEntityResponse>({
+            url: this.opts.config.addr + "/admin/updateConfigEntity",
+            method: "post",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async DeleteConfigEntity(req: DeleteConfigEntityRequest): Promise<DeleteConfigEntityResponse> {
+        let rsp = await this.client.request<DeleteConfigEntityResponse>({
+            url: this.opts.config.addr + "/admin/deleteConfigEntity",
+            method: "post",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+
+    async CreateAPIToken(req: CreateAPITokenRequest): Promise<CreateAPITokenResponse> {
+        let rsp = await this.client.request<CreateAPITokenResponse>({
+            url: this.opts.config.addr + "/admin/createAPIToken",
+            method: "post",
+            data: req,
+            headers: {
+                "Content-Type": "application/json; charset=utf-8",
+            },
+        });
+
+        return rsp.data
+    }
+}
@@ -9,52 +9,46 @@ interface SequenceViewProps {
     sequenceConfig: client.SequenceConfig
 }
 
-export const SequenceView = (props: SequenceViewProps) => {
-    const [currentStepIndex, setCurrentStepIndex] = React.useState<number>(0);
-    const [lastExecutedStepIndex, setLastExecutedIndex] = React.useState<number>(0);
-    const [executeCommandRsps, setExecuteCommandRsps] = React.useState<client.ExecuteCommandResponse[]>([...Array(props.sequenceConfig.Steps.length)]);
-    const [values, setValues] = React.useState<{ [name: string]: string }>({});
-    const [isLoading, setIsLoading] = React.useState<boolean>(true);
-    const [count, setCount] = React.useState<number>(0);
-    const [updateCount, setUpdateCount] = React.useState<number>(0);
-    const stepsCount = props.sequenceConfig.Steps.length
+// sequenceInputConfigs returns the inputs of the commands of the steps, cleanup steps and included sequences, each once
+const sequenceInputConfigs = (sequence: client.SequenceConfig | undefined, seen: { [slug: string]: boolean }): client.CommandInputConfig[] => {
+    if (!sequence) {
+        return []
+    }
 
-    let seenInputs: { [name: string]: boolean } = {}
-    const inputConfigs: client.CommandInputConfig[][] = props.sequenceConfig.Steps.map((s: client.StepConfig, i: number) => {
-        let stepInputs: client.CommandInputConfig[] = []
+    let inputConfigs: client.CommandInputConfig[] = []
+    const steps = [...(sequence.Steps || []), ...(sequence.OnFailure || []), ...(sequence.Finally || [])]
+    steps.forEach((s: client.StepConfig) => {
+        if (s.Sequence?.Slug) {
+            inputConfigs.push(...sequenceInputConfigs(s.Sequence, seen))
 
-        if (!s.Command.Inputs) {
-            return []
+            return
         }
 
-        s.Command.Inputs.forEach(((input) => {
-            if (seenInputs[input.Input.Slug]) {
+        (s.Command?.Inputs || []).forEach((input) => {
+            if (seen[input.Input.Slug]) {
                 return
             }
 
-            seenInputs[input.Input.Slug] = true
-            stepInputs.push(input)
-        }))
-
-        return stepInputs
+            seen[input.Input.Slug] = true
+            inputConfigs.push(input)
+        })
     })
 
-    const firstInputRefs = useRef([]);
-    // @ts-ignore
-    firstInputRefs.current = [...Array(stepsCount)].map((ref, index) => {
-        return React.createRef()
-    })
+    return inputConfigs
+}
 
-    const stepHeaderRefs = useRef<any>([]);
-    const addToStepHeaderRefs = (el: React.Ref<any>) => {
-        if (el && !stepHeaderRefs.current.includes(el)) {
-            stepHeaderRefs.current.push(el);
-        }
-    }
+export const SequenceView = (props: SequenceViewProps) => {
+    const [result, setResult] = React.useState<client.SequenceResult | undefined>(undefined);
+    const [values, setValues] = React.useState<{ [name: string]: string }>({});
+    const [isLoading, setIsLoading] = React.useState<boolean>(false);
+    const [count, setCount] = React.useState<number>(0);
+    const [updateCount, setUpdateCount] = React.useState<number>(0);
 
-    let stepsBodyRef = useRef<any>(null);
+    const inputConfigs = sequenceInputConfigs(props.sequenceConfig, {})
 
-    const setStepInputValue = ((i: number, name: string, value: string) => {
+    const firstInputRef = useRef<any>(null);
+
+    const setInputValue = ((name: string, value: string) => {
         let valuesCopy = Object.assign({}, values);
         valuesCopy[name] = value
         setValues(valuesCopy)
@@ -68,10 +62,6 @@ export const SequenceView = (props: SequenceViewProps) => {
         commandInputsConfigs: client.CommandInputConfig[],
         values: { [name: string]: string }
     ): client.InputValue[] => {
-        if (!commandInputsConfigs) {
-            return []
-        }
-
         let inputValues: client.InputValue[] = []
         commandInputsConfigs.forEach((c) => {
             if (!values[c.Input.Slug]) {
@@ -87,171 +77,162 @@ export const SequenceView = (props: SequenceViewProps) => {
         return inputValues
     }
 
-    const handleFocus = (stepIndex: number) => {
-        setTimeout(() => {
-            let blurIndex = stepIndex === 0 ? stepsCount - 1 : stepIndex - 1
-            // @ts-ignore
-            if (firstInputRefs.current[blurIndex].current != null) {
-                // @ts-ignore
-                firstInputRefs.current[blurIndex].current.blur()
-            }
-
-            // @ts-ignore
-            if (firstInputRefs.current[stepIndex].current != null) {
-                // @ts-ignore
-                firstInputRefs.current[stepIndex].current.focus()
-            }
-        }, 0)
-    }
-
     useEffect(() => {
-        let inputValues = toInputValues(props.sequenceConfig.Steps[currentStepIndex].Command.Inputs, values)
-
-        let executeCommandRspsCopy = [...executeCommandRsps]
-        if (currentStepIndex == 0) {
-            setExecuteCommandRsps([...Array(props.sequenceConfig.Steps.length)]);
-            executeCommandRspsCopy = [...Array(props.sequenceConfig.Steps.length)]
-
-            let firstStepValuesOnly: { [name: string]: string } = {}
-            inputConfigs[0].forEach((commandInputConfig => {
-                firstStepValuesOnly[commandInputConfig.Input.Slug] = values[commandInputConfig.Input.Slug]
-            }))
-
-            setValues(firstStepValuesOnly)
-        }
-
-        if (inputConfigs[currentStepIndex] && inputConfigs[currentStepIndex].length !== 0 && inputValues?.length === 0) {
-            setIsLoading(false);
+        let inputValues = toInputValues(inputConfigs, values)
 
+        // a sequence with inputs runs once they are entered, a sequence without inputs runs right away
+        if (count === 0 && inputConfigs.length !== 0) {
             return
         }
 
-        let currentStepIndexCopy = currentStepIndex
-
         setIsLoading(true);
 
         (async function () {
-            let req: client.ExecuteCommandRequest = {
-                Slug: props.sequenceConfig.Steps[currentStepIndex].Command.Slug,
+            let req: client.ExecuteSequenceRequest = {
+                Slug: props.sequenceConfig.Slug,
                 Inputs: inputValues,
             }
-            const viewOutputRsp = await props.client.ExecuteCommand(req)
+            const rsp = await props.client.ExecuteSequence(req)
 
-            executeCommandRspsCopy[currentStepIndex] = viewOutputRsp
-
-            if (viewOutputRsp.Output.ExitCode == 0) {
-                if (currentStepIndexCopy + 1 >= stepsCount) {
-                    currentStepIndexCopy = 0
-                    if (inputConfigs[0].length != 0) {
-                        let valuesCopy = Object.assign({}, values)
-
-                        inputConfigs[0].forEach((commandInputConfig => {
-                            valuesCopy[commandInputConfig.Input.Slug] = ""
-                        }))
-
-                        setValues(valuesCopy)
-                    }
-                } else {
-                    currentStepIndexCopy++
-                }
-            }
-
-            setLastExecutedIndex(currentStepIndex);
-            setCurrentStepIndex(currentStepIndexCopy);
-            setExecuteCommandRsps(executeCommandRspsCopy);
+            setResult(rsp.Result)
             setIsLoading(false);
             setUpdateCount(updateCount + 1)
 
-            if (currentStepIndex > 1) {
-                stepsBodyRef.current?.scrollTo({
-                    // @ts-ignore
-                    top: stepHeaderRefs.current[currentStepIndexCopy].offsetTop - 300,
-                    behavior: 'smooth'
-                });
-            }
-
-            handleFocus(currentStepIndexCopy)
-
-            if (viewOutputRsp.Output.ExitCode == 0 && currentStepIndexCopy && (!inputConfigs[currentStepIndexCopy] || inputConfigs[currentStepIndexCopy].length === 0)) {
-                setTimeout(() => {
-                    refresh()
-                }, 0)
-            }
+            setTimeout(() => {
+                firstInputRef.current?.focus()
+            }, 0)
         })().catch((reason: any) => {
-            message.error('failed to get step output: ' + reason);
+            message.error('failed to execute sequence: ' + reason);
             setIsLoading(false);
         });
     }, [count]);
 
-    const filename = `${props.name.replaceAll(" ", "_")}_${(new Date()).toISOString().slice(0, 19).replace("T", "_")}.txt`
-
     return (
         <div className="views__view" key={props.name}>
             <div className="views__view__header">
                 <span className="views__view__header__name">
                     {props.name}
                 </span>
+                {isLoading ? <span className="views__view__header__loader"><div
+                    className={"loader--" + props.viewConfig.Category.Slug}/></span> : null}
+                {result ? <span className={"views__view__step__status views__view__step__status--" + result.Status}>
+                    {result.Status}
+                </span> : null}
+                <span className="views__view__header__raw">
+                    <a rel="noreferrer" onClick={refresh}>Run </a>
+                </span>
+                {inputConfigs.length !== 0 ?
+                    <ViewEnv inputConfigs={inputConfigs}
+                             inputValues={toInputValues(inputConfigs, values)}
+                             ref={firstInputRef}
+                             setInputValue={setInputValue}
+                             disabled={isLoading}
+                             refresh={refresh}/> : null}
             </div>
-            <div className={"views__view__body scrollbar-color--" + props.viewConfig.Category.Slug} ref={stepsBodyRef}>
+            <div className={"views__view__body scrollbar-color--" + props.viewConfig.Category.Slug}>
                 <div className="views__view__steps">
-                    {props.sequenceConfig.Steps.map((step: client.StepConfig, stepIndex: number) => {
-                        let className = "views__view__step"
-                        if (stepIndex === currentStepIndex && !isLoading) {
-                            className = className + " a-color--" + props.viewConfig.Category.Slug + " input-background--" + props.viewConfig.Category.Slug
-                        } else {
-                            className = className + " views__view__step--disabled"
-                        }
-
-                        let inputValues = toInputValues(props.sequenceConfig.Steps[stepIndex].Command.Inputs, values)
-                        let rawReq: client.ExecuteCommandRequest = {
-                            Slug: props.sequenceConfig.Steps[stepIndex].Command.Slug,
-                            Inputs: inputValues,
-                        }
-                        rawReq.Format = client.FormatRaw;
-
-                        let outputClassName = "views__view__output"
-                        if (stepIndex == lastExecutedStepIndex && executeCommandRsps[stepIndex] && executeCommandRsps[stepIndex].Output) {
-                            outputClassName = outputClassName + " flash-border--" + props.viewConfig.Category.Slug + updateCount % 2
-                        }
-
-                        return (
-                            // @ts-ignore
-                            <div className={className} key={props.name + stepIndex} ref={addToStepHeaderRefs}>
-                                <div className="views__view__step__header">
-                                <span className="views__view__step__header__name">
-                                    #{stepIndex + 1} {step.Name}
-                                </span>
-                                    {isLoading && stepIndex === currentStepIndex ?
-                                        <span className="views__view__header__loader"><div
-                                            className={"loader--" + props.viewConfig.Category.Slug}/></span> : null}
-                                    <span className="views__view__header__raw">
-                                    <a rel="noreferrer" onClick={refresh}>Run </a>
-                                    <a href={props.client.ExecuteCommandLink(rawReq)} target="_blank"
-                                       rel="noreferrer">Raw </a>
-                                    <a href={props.client.ExecuteCommandLink(rawReq)} target="_blank" rel="noreferrer"
-                                       download={filename}>Download </a>
-                                </span>
-                                    {inputConfigs[stepIndex] ?
-                                        <ViewEnv inputConfigs={inputConfigs[stepIndex]}
-                                                 inputValues={inputValues}
-                                                 ref={firstInputRefs.current[stepIndex]}
-                                                 setInputValue={(k, v: string) => setStepInputValue(stepIndex, k, v)}
-                                                 disabled={stepIndex !== currentStepIndex || isLoading}
-                                            // disabled={false}
-                                                 refresh={refresh}/> : null}
-                                </div>
-                                <div className={outputClassName}>
-                                    {executeCommandRsps[stepIndex]?.Output?.Stdout ? executeCommandRsps[stepIndex]?.Output?.Stdout : executeCommandRsps[stepIndex]?.Output?.Stderr}
-                                </div>
-                            </div>
-                        )
-                    })}
+                    <StepResults prefix="#"
+                                 steps={props.sequenceConfig.Steps}
+                                 results={result?.Steps}
+                                 category={props.viewConfig.Category.Slug}
+                                 flash={updateCount % 2}/>
+                    <StepResults prefix="on failure #" onlyIfRun={true}
+                                 steps={props.sequenceConfig.OnFailure}
+                                 results={result?.OnFailure}
+                                 category={props.viewConfig.Category.Slug}
+                                 flash={updateCount % 2}/>
+                    <StepResults prefix="finally #" onlyIfRun={true}
+                                 steps={props.sequenceConfig.Finally}
+                                 results={result?.Finally}
+                                 category={props.viewConfig.Category.Slug}
+                                 flash={updateCount % 2}/>
                 </div>
             </div>
         </div>
     );
 }
 
+// outputText returns the error of a step or item, or else its stdout or else its stderr
+const outputText = (r: { Error: string, Output: client.CommandOutput }): string => {
+    if (r.Error) {
+        return r.Error
+    }
+
+    return r.Output.Stdout ? r.Output.Stdout : r.Output.Stderr
+}
+
+interface StepResultsProps {
+    prefix: string
+    // onlyIfRun hides the steps until they ran, e.g. cleanup steps
+    onlyIfRun?: boolean
+    steps?: client.StepConfig[]
+    results?: client.StepResult[]
+    category: string
+    flash: number
+}
+
+// StepResults renders the steps with their results once the sequence ran
+const StepResults = (props: StepResultsProps) => {
+    if (!props.steps || (props.onlyIfRun && !props.results)) {
+        return null
+    }
+
+    return (
+        <>
+            {props.steps.map((step: client.StepConfig, stepIndex: number) => {
+                const result = props.results ? props.results[stepIndex] : undefined
+
+                let outputClassName = "views__view__output"
+                if (result) {
+                    outputClassName = outputClassName + " flash-border--" + props.category + props.flash
+                }
+
+                return (
+                    <div className="views__view__step" key={props.prefix + stepIndex}>
+                        <div className="views__view__step__header">
+                            <span className="views__view__step__header__name">
+                                {props.prefix}{stepIndex + 1} {step.Name}
+                            </span>
+                            {result ? <span className={"views__view__step__status views__view__step__status--" + result.Status}>
+                                {result.Status}{result.Attempts > 1 ? " after " + result.Attempts + " attempts" : ""}
+                            </span> : null}
+                        </div>
+                        {result && result.Sequence ?
+                            <div className="views__view__step__sequence">
+                                <StepResults prefix={props.prefix + (stepIndex + 1) + "."}
+                                             steps={step.Sequence.Steps}
+                                             results={result.Sequence.Steps}
+                                             category={props.category}
+                                             flash={props.flash}/>
+                            </div> : null}
+                        {result && result.Items ? result.Items.map((item: client.StepItemResult, itemIndex: number) => {
+                            return (
+                                <div key={itemIndex}>
+                                    <div className="views__view__step__header">
+                                        <span className="views__view__step__header__name">
+                                            {step.Foreach?.As}={item.Value}
+                                        </span>
+                                        <span className={"views__view__step__status views__view__step__status--" + item.Status}>
+                                            {item.Status}
+                                        </span>
+                                    </div>
+                                    <div className={outputClassName}>
+                                        {outputText(item)}
+                                    </div>
+                                </div>
+                            )
+                        }) : null}
+                        {!result?.Sequence && !result?.Items ?
+                            <div className={outputClassName}>
+                                {result ? outputText(result) : null}
+                            </div> : null}
+                    </div>
+                )
+            })}
+        </>
+    )
+}
+
 interface ViewEnvProps {
     inputConfigs: client.CommandInputConfig[]
     inputValues: client.InputValue[]
@@ -1,56 +1,476 @@
 package domain
 
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 type UserConfig struct {
 	ID     string
 	Groups []GroupConfig
 }
 
+// IsAdmin returns whether the user is granted a role that may change the config
+func (u UserConfig) IsAdmin() bool {
+	for i := range u.Groups {
+		for ii := range u.Groups[i].Roles {
+			if u.Groups[i].Roles[ii].Admin {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AllowedViews returns the slugs of the views the roles of the user grant, including the views of nested categories
+func (u UserConfig) AllowedViews() map[string]struct{} {
+	allowedViews := map[string]struct{}{}
+	for i := range u.Groups {
+		for ii := range u.Groups[i].Roles {
+			for _, v := range u.Groups[i].Roles[ii].Views {
+				allowedViews[v.Slug] = struct{}{}
+			}
+
+			for _, c := range u.Groups[i].Roles[ii].Categories {
+				for _, v := range c.AllViews() {
+					allowedViews[v.Slug] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return allowedViews
+}
+
+// AllowedCategories returns the slugs of the categories the roles of the user grant and of the categories of granted views
+func (u UserConfig) AllowedCategories() map[string]struct{} {
+	allowedCategories := map[string]struct{}{}
+	for i := range u.Groups {
+		for ii := range u.Groups[i].Roles {
+			for _, c := range FlattenCategories(u.Groups[i].Roles[ii].Categories) {
+				allowedCategories[c.Slug] = struct{}{}
+			}
+
+			for _, v := range u.Groups[i].Roles[ii].Views {
+				allowedCategories[v.Category.Slug] = struct{}{}
+			}
+		}
+	}
+
+	return allowedCategories
+}
+
+// AllowedCommands returns the slugs of the commands the allowed views of the user run, including the commands of sequences
+func (u UserConfig) AllowedCommands() map[string]struct{} {
+	allowedCommands := map[string]struct{}{}
+	for i := range u.Groups {
+		for ii := range u.Groups[i].Roles {
+			var views []ViewConfig
+			views = append(views, u.Groups[i].Roles[ii].Views...)
+			for _, c := range u.Groups[i].Roles[ii].Categories {
+				views = append(views, c.AllViews()...)
+			}
+
+			for _, v := range views {
+				allowedCommands[v.Command.Slug] = struct{}{}
+
+				for slug := range v.Sequence.CommandSlugs() {
+					allowedCommands[slug] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return allowedCommands
+}
+
+// ViewGrants returns the constraints of each grant of the view to the user, a view that is granted
+// through a category or without constraints is granted with no constraints
+func (u UserConfig) ViewGrants(slug string) [][]InputConstraint {
+	var grants [][]InputConstraint
+	for i := range u.Groups {
+		for ii := range u.Groups[i].Roles {
+			for _, g := range u.Groups[i].Roles[ii].ViewGrants {
+				if g.ViewSlug == slug {
+					grants = append(grants, g.Constraints)
+				}
+			}
+
+			for _, c := range u.Groups[i].Roles[ii].Categories {
+				for _, v := range c.AllViews() {
+					if v.Slug == slug {
+						grants = append(grants, nil)
+					}
+				}
+			}
+		}
+	}
+
+	return grants
+}
+
 type GroupConfig struct {
 	Slug  string
 	Roles []RoleConfig
+	// HeaderGroups are the names of the group in the groups header or groups claim in addition to its slug
+	HeaderGroups []string
 }
 
 type RoleConfig struct {
 	Slug       string
 	Views      []ViewConfig
 	Categories []CategoryConfig
+	Admin      bool
+	// ViewGrants are the constraints on input values the views are granted with, one per view in Views
+	ViewGrants []ViewGrant
+}
+
+// ViewGrant grants a view for input values that satisfy all constraints
+type ViewGrant struct {
+	ViewSlug    string
+	Constraints []InputConstraint
+}
+
+// InputConstraint restricts the values of an input
+type InputConstraint struct {
+	InputSlug string
+	// Values the input may have, if set
+	Values []string
+	// Regex the whole value has to match, if set
+	Regex string
+	// Not negates the constraint
+	Not bool
+	// regexp is Regex compiled by NewInputConstraint
+	regexp *regexp.Regexp
+}
+
+// NewInputConstraint returns the constraint with its regex compiled, so that it isn't compiled per check
+func NewInputConstraint(inputSlug string, values []string, regex string, not bool) (InputConstraint, error) {
+	c := InputConstraint{
+		InputSlug: inputSlug,
+		Values:    values,
+		Regex:     regex,
+		Not:       not,
+	}
+
+	if regex != "" {
+		var err error
+		c.regexp, err = regexp.Compile(`^(?:` + regex + `)$`)
+		if err != nil {
+			return InputConstraint{}, errors.Wrapf(err, "failed to compile regex=%v", regex)
+		}
+	}
+
+	return c, nil
+}
+
+// UnmarshalJSON compiles the regex of a stored constraint
+func (c *InputConstraint) UnmarshalJSON(b []byte) error {
+	type stored InputConstraint
+	var s stored
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	*c, err = NewInputConstraint(s.InputSlug, s.Values, s.Regex, s.Not)
+
+	return err
+}
+
+// Holds returns whether value satisfies the constraint
+func (c InputConstraint) Holds(value string) bool {
+	holds := true
+	if c.Values != nil {
+		holds = false
+		for i := range c.Values {
+			if c.Values[i] == value {
+				holds = true
+				break
+			}
+		}
+	}
+
+	if c.regexp != nil {
+		holds = holds && c.regexp.MatchString(value)
+	}
+
+	return holds != c.Not
 }
 
 type ViewConfig struct {
-	Slug     string
-	Name     string
-	Command  CommandConfig
-	Sequence SequenceConfig
-	Category CategoryConfig
+	Slug             string
+	Name             string
+	Description      string
+	Command          CommandConfig
+	Sequence         SequenceConfig
+	Category         CategoryConfig
+	RequiresApproval *ApprovalConfig
+	Inputs           []ViewInputConfig
+	Order            int
+	Tags             []string
+}
+
+// HasLockedInputs returns whether users of the view can't change the value of some input of its command
+func (v ViewConfig) HasLockedInputs() bool {
+	for i := range v.Inputs {
+		if v.Inputs[i].Locked {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ViewInputConfig presets the value of an input of the command of a view
+type ViewInputConfig struct {
+	InputSlug string
+	Value     string
+	Locked    bool
 }
 
 type CategoryConfig struct {
-	Slug  string
-	Name  string
-	Color string
-	Views []ViewConfig
+	Slug   string
+	Name   string
+	Color  string
+	Parent string
+	Order  int
+	Views  []ViewConfig
+	// Categories are the categories nested in the category
+	Categories []CategoryConfig
+}
+
+// AllViews returns the views of the category and of the categories nested in it
+func (c CategoryConfig) AllViews() []ViewConfig {
+	views := append([]ViewConfig{}, c.Views...)
+	for i := range c.Categories {
+		views = append(views, c.Categories[i].AllViews()...)
+	}
+
+	return views
+}
+
+// FlattenCategories returns the categories and the categories nested in them, parents before their children
+func FlattenCategories(categories []CategoryConfig) []CategoryConfig {
+	var flattened []CategoryConfig
+	for i := range categories {
+		flattened = append(flattened, categories[i])
+		flattened = append(flattened, FlattenCategories(categories[i].Categories)...)
+	}
+
+	return flattened
 }
 
 type SequenceConfig struct {
-	Slug  string
-	Steps []StepConfig
+	Slug      string
+	Steps     []StepConfig
+	OnFailure []StepConfig
+	Finally   []StepConfig
+}
+
+// CommandSlugs returns the slugs of all commands that may run as part of the sequence,
+// including the commands of the onFailure and finally steps and of included sequences
+func (s SequenceConfig) CommandSlugs() map[string]struct{} {
+	slugs := map[string]struct{}{}
+	for _, steps := range [][]StepConfig{s.Steps, s.OnFailure, s.Finally} {
+		for i := range steps {
+			if steps[i].Command.Slug != "" {
+				slugs[steps[i].Command.Slug] = struct{}{}
+			}
+
+			for slug := range steps[i].Sequence.CommandSlugs() {
+				slugs[slug] = struct{}{}
+			}
+		}
+	}
+
+	return slugs
 }
 
 type StepConfig struct {
-	Name    string
-	Command CommandConfig
+	Slug            string
+	Name            string
+	Command         CommandConfig
+	Sequence        SequenceConfig
+	Needs           []string
+	Foreach         *StepForeachConfig
+	Retries         int
+	Backoff         time.Duration
+	ContinueOnError bool
+	When            []StepConditionConfig
+	Inputs          []StepInputConfig
+	Outputs         []StepOutputConfig
+}
+
+type StepForeachConfig struct {
+	InputSlug string
+	Values    []string
+	As        string
+	Parallel  bool
+	// MaxParallel limits how many items run at once if Parallel is set, zero means the default limit
+	MaxParallel int
+}
+
+type StepConditionConfig struct {
+	StepSlug  string
+	ExitCodes []int
+}
+
+type StepInputConfig struct {
+	InputSlug  string
+	StepSlug   string
+	OutputName string
+	FromInput  string
+}
+
+type StepOutputConfig struct {
+	Name     string
+	Regex    string
+	JSONPath string
 }
 
 type CommandConfig struct {
-	Slug    string
-	Command string
-	Inputs  []CommandInputConfig
+	Slug             string
+	Command          string
+	Description      string
+	Inputs           []CommandInputConfig
+	Secrets          []CommandSecretConfig
+	RedactRules      []RedactRule
+	RequiresApproval *ApprovalConfig
+}
+
+// RedactRule replaces matches of Regex in the output of a command, Replacement may reference groups of Regex
+type RedactRule struct {
+	Regex       string
+	Replacement string
+	// regexp is Regex compiled by NewRedactRule
+	regexp *regexp.Regexp
+}
+
+// NewRedactRule returns the rule with its regex compiled, so that it isn't compiled per execution
+func NewRedactRule(regex string, replacement string) (RedactRule, error) {
+	r, err := regexp.Compile(regex)
+	if err != nil {
+		return RedactRule{}, errors.Wrapf(err, "failed to compile regex=%v", regex)
+	}
+
+	return RedactRule{
+		Regex:       regex,
+		Replacement: replacement,
+		regexp:      r,
+	}, nil
+}
+
+// UnmarshalJSON compiles the regex of a stored rule
+func (r *RedactRule) UnmarshalJSON(b []byte) error {
+	type stored RedactRule
+	var s stored
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	*r, err = NewRedactRule(s.Regex, s.Replacement)
+
+	return err
+}
+
+// Apply replaces the matches in s and returns the number of replaced matches
+func (r RedactRule) Apply(s string) (string, int) {
+	if r.regexp == nil {
+		return s, 0
+	}
+
+	return r.regexp.ReplaceAllString(s, r.Replacement), len(r.regexp.FindAllStringIndex(s, -1))
+}
+
+// CommandSecretConfig references a secret that is injected into the command as the env var Env,
+// the value of the secret is not part of the config
+type CommandSecretConfig struct {
+	SecretSlug string
+	Env        string
+}
+
+type ApprovalConfig struct {
+	RoleSlugs []string
+	Expiry    time.Duration
 }
 
 type CommandInputConfig struct {
 	Input InputConfig
 }
 
+const (
+	InputTypeString    = "string"
+	InputTypeInt       = "int"
+	InputTypeBool      = "bool"
+	InputTypeEnum      = "enum"
+	InputTypeMultiline = "multiline"
+	InputTypeDate      = "date"
+	InputTypeSecret    = "secret"
+)
+
+const inputDateLayout = "2006-01-02"
+
 type InputConfig struct {
-	Slug string
+	Slug               string
+	Type               string
+	Default            string
+	Label              string
+	Description        string
+	Placeholder        string
+	Values             []string
+	OptionsCommandSlug string
+	OptionsTTL         time.Duration
+}
+
+// Convert converts a value to the canonical form of the input type, e.g. "1" of a bool to "true"
+func (i InputConfig) Convert(value string) (string, error) {
+	switch i.Type {
+	case InputTypeString, InputTypeSecret, "":
+		return value, nil
+	case InputTypeMultiline:
+		return strings.ReplaceAll(value, "\r\n", "\n"), nil
+	case InputTypeInt:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return "", errors.Errorf("value=%v is not an int", value)
+		}
+
+		return strconv.FormatInt(n, 10), nil
+	case InputTypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", errors.Errorf("value=%v is not a bool", value)
+		}
+
+		return strconv.FormatBool(b), nil
+	case InputTypeEnum:
+		for _, v := range i.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+
+		if i.OptionsCommandSlug != "" {
+			return value, nil
+		}
+
+		return "", errors.Errorf("value=%v is not one of values=%v", value, i.Values)
+	case InputTypeDate:
+		d, err := time.Parse(inputDateLayout, strings.TrimSpace(value))
+		if err != nil {
+			return "", errors.Errorf("value=%v is not a date of format=%v", value, inputDateLayout)
+		}
+
+		return d.Format(inputDateLayout), nil
+	default:
+		return "", errors.Errorf("unknown type=%v", i.Type)
+	}
 }
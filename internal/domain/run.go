@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+const (
+	RunStatusPending  = "pending"
+	RunStatusApproved = "approved"
+	RunStatusRejected = "rejected"
+	RunStatusExpired  = "expired"
+	// RunStatusFailed is the status of an approved run whose command failed to execute
+	RunStatusFailed = "failed"
+)
+
+type Run struct {
+	ID          string
+	CommandSlug string
+	Inputs      []RunInput
+	Status      string
+	RequestedBy string
+	RequestedAt time.Time
+	ExpiresAt   time.Time
+	ReviewedBy  string
+	ReviewedAt  time.Time
+	Output      RunOutput
+	// Error is why the command of an approved run failed to execute
+	Error string
+}
+
+type RunInput struct {
+	Name  string
+	Value string
+}
+
+type RunOutput struct {
+	Stdout     string
+	Stderr     string
+	ExitCode   int
+	Redactions int
+}
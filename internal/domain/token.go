@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// APIToken authenticates a machine client on behalf of its owner, only the hash of the token is known
+type APIToken struct {
+	Slug string
+	// Owner is the id of the user whose permissions the token is limited to
+	Owner string
+	// Hash is the hex encoded sha256 hash of the token
+	Hash      string
+	ExpiresAt time.Time
+	// CommandSlugs and ViewSlugs are the scopes of the token, the commands that may be executed directly and the views
+	CommandSlugs []string
+	ViewSlugs    []string
+}
+
+// HashAPIToken returns the hex encoded sha256 hash of a token as it is stored
+func HashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(h[:])
+}
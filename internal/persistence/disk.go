@@ -0,0 +1,257 @@
+package persistence
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+var (
+	// configsBucket holds one record per part of the configs, e.g. the views
+	configsBucket = []byte("configs")
+	// runsBucket holds one record per run by its id
+	runsBucket = []byte("runs")
+)
+
+// storedRun is the record of a run, the values of secret inputs are not stored
+type storedRun struct {
+	domain.Run
+	// RedactedInputs are the names of the secret inputs whose values were not stored
+	RedactedInputs []string
+}
+
+// DiskRepository keeps the configs and the runs in a bbolt database, so that they survive restarts,
+// and serves them from memory like MemoryRepository and MemoryRunRepository. Changes are written
+// to the database before they are served. Secret values and secret inputs of runs are not stored.
+type DiskRepository struct {
+	MemoryRepository
+	runs MemoryRunRepository
+	db   *bbolt.DB
+	// mu serializes changes, so that the database and memory change in the same order
+	mu         *sync.Mutex
+	configured *atomic.Bool
+}
+
+// OpenDiskRepository opens the database at path, it is created if it doesn't exist yet
+func OpenDiskRepository(path string) (DiskRepository, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return DiskRepository{}, errors.Wrapf(err, "failed to open database path=%v", path)
+	}
+
+	r := DiskRepository{
+		MemoryRepository: NewMemoryRepository(RepositoryOpts{}),
+		runs:             NewMemoryRunRepository(),
+		db:               db,
+		mu:               &sync.Mutex{},
+		configured:       &atomic.Bool{},
+	}
+
+	err = r.load()
+	if err != nil {
+		_ = db.Close()
+
+		return DiskRepository{}, errors.Wrapf(err, "failed to load database path=%v", path)
+	}
+
+	return r, nil
+}
+
+// load creates the buckets and reads the stored configs and runs into memory
+func (r DiskRepository) load() error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		configs, err := tx.CreateBucketIfNotExists(configsBucket)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create bucket=%s", configsBucket)
+		}
+
+		runs, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create bucket=%s", runsBucket)
+		}
+
+		if configs.Stats().KeyN != 0 {
+			var opts RepositoryOpts
+			for key, v := range configRecords(&opts) {
+				b := configs.Get([]byte(key))
+				if b == nil {
+					continue
+				}
+
+				err := json.Unmarshal(b, v)
+				if err != nil {
+					return errors.Wrapf(err, "failed to json unmarshal config=%v", key)
+				}
+			}
+
+			r.MemoryRepository.Swap(opts)
+			r.configured.Store(true)
+		}
+
+		return runs.ForEach(func(k, v []byte) error {
+			var run storedRun
+			err := json.Unmarshal(v, &run)
+			if err != nil {
+				return errors.Wrapf(err, "failed to json unmarshal run id=%s", k)
+			}
+
+			// a pending run can't execute without the values of its secret inputs
+			if run.Status == domain.RunStatusPending && len(run.RedactedInputs) != 0 {
+				run.Status = domain.RunStatusFailed
+				run.Error = "the values of the secret inputs of the run were not stored, the run has to be requested again"
+			}
+
+			r.runs.runs[run.ID] = run.Run
+
+			return nil
+		})
+	})
+}
+
+// configRecords returns the parts of opts that are stored by their key, secret values are not stored
+func configRecords(opts *RepositoryOpts) map[string]interface{} {
+	return map[string]interface{}{
+		"views":                 &opts.ViewConfigs,
+		"users":                 &opts.UserConfigs,
+		"groups":                &opts.GroupConfigs,
+		"userAllowedViews":      &opts.UserAllowedViews,
+		"userAllowedCategories": &opts.UserAllowedCategories,
+		"userAllowedCommands":   &opts.UserAllowedCommands,
+		"commands":              &opts.CommandConfigs,
+		"sequences":             &opts.SequenceConfigs,
+		"categories":            &opts.CategoryConfigs,
+		"apiTokens":             &opts.APITokens,
+	}
+}
+
+// Close closes the database
+func (r DiskRepository) Close() error {
+	return r.db.Close()
+}
+
+// HasConfig returns whether a config was stored, e.g. imported from yaml before a restart
+func (r DiskRepository) HasConfig() bool {
+	return r.configured.Load()
+}
+
+// Swap stores opts, without the secret values, and atomically replaces the opts that are served
+func (r DiskRepository) Swap(opts RepositoryOpts) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		configs := tx.Bucket(configsBucket)
+		for key, v := range configRecords(&opts) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return errors.Wrapf(err, "failed to json marshal config=%v", key)
+			}
+
+			err = configs.Put([]byte(key), b)
+			if err != nil {
+				return errors.Wrapf(err, "failed to put config=%v", key)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to store configs")
+	}
+
+	r.MemoryRepository.Swap(opts)
+	r.configured.Store(true)
+
+	return nil
+}
+
+func (r DiskRepository) CreateRun(run domain.Run) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.runs.GetRun(run.ID)
+	if ok {
+		return errutil.AlreadyExists(errutil.Nil(), "Run", run.ID)
+	}
+
+	err := r.putRun(run)
+	if err != nil {
+		return err
+	}
+
+	return r.runs.CreateRun(run)
+}
+
+func (r DiskRepository) GetRun(id string) (domain.Run, bool) {
+	return r.runs.GetRun(id)
+}
+
+func (r DiskRepository) GetRuns() []domain.Run {
+	return r.runs.GetRuns()
+}
+
+// UpdateRun applies update to the run and stores the updated run before it is served,
+// concurrent updates are serialized like by MemoryRunRepository
+func (r DiskRepository) UpdateRun(id string, update func(run domain.Run) (domain.Run, error)) (domain.Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs.GetRun(id)
+	if !ok {
+		return domain.Run{}, errutil.NotFound(errutil.Nil(), "Run", id)
+	}
+
+	run, err := update(run)
+	if err != nil {
+		return domain.Run{}, errors.Wrapf(err, "failed to update run id=%v", id)
+	}
+
+	err = r.putRun(run)
+	if err != nil {
+		return domain.Run{}, err
+	}
+
+	return r.runs.UpdateRun(id, func(domain.Run) (domain.Run, error) {
+		return run, nil
+	})
+}
+
+// putRun stores the run without the values of the secret inputs of its command
+func (r DiskRepository) putRun(run domain.Run) error {
+	command, _ := r.GetCommandConfig(run.CommandSlug)
+
+	stored := storedRun{Run: run}
+	stored.Inputs = make([]domain.RunInput, len(run.Inputs))
+	copy(stored.Inputs, run.Inputs)
+	for i := range stored.Inputs {
+		for _, input := range command.Inputs {
+			if input.Input.Slug == stored.Inputs[i].Name && input.Input.Type == domain.InputTypeSecret {
+				stored.Inputs[i].Value = ""
+				stored.RedactedInputs = append(stored.RedactedInputs, stored.Inputs[i].Name)
+			}
+		}
+	}
+	sort.Strings(stored.RedactedInputs)
+
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return errors.Wrapf(err, "failed to json marshal run id=%v", run.ID)
+	}
+
+	err = r.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(runsBucket).Put([]byte(run.ID), b)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to store run id=%v", run.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package persistence
+
+import (
+	"sync"
+	"time"
+)
+
+// maxInputOptionsEntries bounds the cache, as keys contain the values of the inputs the options depend on
+const maxInputOptionsEntries = 1000
+
+type inputOptionsEntry struct {
+	options   []string
+	expiresAt time.Time
+}
+
+type InputOptionsCache struct {
+	mu      *sync.Mutex
+	entries map[string]inputOptionsEntry
+}
+
+func NewInputOptionsCache() InputOptionsCache {
+	return InputOptionsCache{
+		mu:      &sync.Mutex{},
+		entries: map[string]inputOptionsEntry{},
+	}
+}
+
+func (c InputOptionsCache) GetInputOptions(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	return entry.options, true
+}
+
+// SetInputOptions caches options for ttl, expired entries are removed and if the cache is full
+// the entry that expires first is evicted
+func (c InputOptionsCache) SetInputOptions(key string, options []string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	_, ok := c.entries[key]
+	if !ok && len(c.entries) >= maxInputOptionsEntries {
+		var first string
+		for k, entry := range c.entries {
+			if first == "" || entry.expiresAt.Before(c.entries[first].expiresAt) {
+				first = k
+			}
+		}
+		delete(c.entries, first)
+	}
+
+	c.entries[key] = inputOptionsEntry{
+		options:   options,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// Clear removes all entries, e.g. after the configs changed
+func (c InputOptionsCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		delete(c.entries, k)
+	}
+}
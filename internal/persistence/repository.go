@@ -1,89 +1,212 @@
 package persistence
 
 import (
+	"sort"
+	"sync/atomic"
+
 	"github.com/ppwfx/shellpane/internal/domain"
 )
 
 type RepositoryOpts struct {
 	ViewConfigs           []domain.ViewConfig
 	UserConfigs           map[string]domain.UserConfig
+	GroupConfigs          map[string]domain.GroupConfig
 	UserAllowedViews      map[string]map[string]struct{}
 	UserAllowedCategories map[string]map[string]struct{}
 	UserAllowedCommands   map[string]map[string]struct{}
 	CommandConfigs        map[string]domain.CommandConfig
+	SequenceConfigs       map[string]domain.SequenceConfig
 	CategoryConfigs       []domain.CategoryConfig
+	SecretValues          map[string]string
+	// APITokens are the api tokens by their hash
+	APITokens map[string]domain.APIToken
+}
+
+// Repository serves the configs and the permissions of users
+type Repository interface {
+	// Swap atomically replaces the configs, e.g. when the config is reloaded
+	Swap(opts RepositoryOpts) error
+	GetUserAllowedViews() map[string]map[string]struct{}
+	GetUserAllowedCategories() map[string]map[string]struct{}
+	GetUserAllowedCommands() map[string]map[string]struct{}
+	GetViewConfigs() []domain.ViewConfig
+	GetViewConfigsIn(slugs map[string]struct{}) []domain.ViewConfig
+	GetCategoryConfigs() []domain.CategoryConfig
+	GetCategoryConfigsIn(slugs map[string]struct{}) []domain.CategoryConfig
+	GetViewConfig(name string) (domain.ViewConfig, bool)
+	GetViewConfigBySlug(slug string) (domain.ViewConfig, bool)
+	GetCommandConfigs() map[string]domain.CommandConfig
+	GetCommandConfig(slug string) (domain.CommandConfig, bool)
+	GetSequenceConfig(slug string) (domain.SequenceConfig, bool)
+	GetUserConfig(id string) (domain.UserConfig, bool)
+	GetHeaderGroupConfigs(names []string) []domain.GroupConfig
+	GetSecretValues() map[string]string
+	GetAPIToken(hash string) (domain.APIToken, bool)
 }
 
-type Repository struct {
-	opts RepositoryOpts
+// MemoryRepository serves configs from opts that can be swapped while serving, e.g. when the config is reloaded
+type MemoryRepository struct {
+	opts *atomic.Pointer[RepositoryOpts]
 }
 
-func NewRepository(opts RepositoryOpts) Repository {
-	return Repository{
-		opts: opts,
+func NewMemoryRepository(opts RepositoryOpts) MemoryRepository {
+	r := MemoryRepository{
+		opts: &atomic.Pointer[RepositoryOpts]{},
 	}
+
+	r.opts.Store(&opts)
+
+	return r
 }
 
-func (r Repository) GetUserAllowedViews() map[string]map[string]struct{} {
-	return r.opts.UserAllowedViews
+// Swap atomically replaces the opts of the repository and of all its copies
+func (r MemoryRepository) Swap(opts RepositoryOpts) error {
+	r.opts.Store(&opts)
+
+	return nil
 }
 
-func (r Repository) GetUserAllowedCategories() map[string]map[string]struct{} {
-	return r.opts.UserAllowedCategories
+func (r MemoryRepository) GetUserAllowedViews() map[string]map[string]struct{} {
+	return r.opts.Load().UserAllowedViews
 }
 
-func (r Repository) GetUserAllowedCommands() map[string]map[string]struct{} {
-	return r.opts.UserAllowedCommands
+func (r MemoryRepository) GetUserAllowedCategories() map[string]map[string]struct{} {
+	return r.opts.Load().UserAllowedCategories
 }
 
-func (r Repository) GetViewConfigs() []domain.ViewConfig {
-	return r.opts.ViewConfigs
+func (r MemoryRepository) GetUserAllowedCommands() map[string]map[string]struct{} {
+	return r.opts.Load().UserAllowedCommands
 }
 
-func (r Repository) GetViewConfigsIn(slugs map[string]struct{}) []domain.ViewConfig {
+func (r MemoryRepository) GetViewConfigs() []domain.ViewConfig {
+	return r.opts.Load().ViewConfigs
+}
+
+func (r MemoryRepository) GetViewConfigsIn(slugs map[string]struct{}) []domain.ViewConfig {
+	viewConfigs := r.GetViewConfigs()
+
 	var views []domain.ViewConfig
-	for i := range r.GetViewConfigs() {
-		_, ok := slugs[r.opts.ViewConfigs[i].Slug]
+	for i := range viewConfigs {
+		_, ok := slugs[viewConfigs[i].Slug]
 		if !ok {
 			continue
 		}
 
-		views = append(views, r.opts.ViewConfigs[i])
+		views = append(views, viewConfigs[i])
 	}
 
 	return views
 }
 
-func (r Repository) GetCategoryConfigs() []domain.CategoryConfig {
-	return r.opts.CategoryConfigs
+func (r MemoryRepository) GetCategoryConfigs() []domain.CategoryConfig {
+	return r.opts.Load().CategoryConfigs
+}
+
+// GetCategoryConfigsIn returns the category tree reduced to the categories in slugs, categories that aren't
+// in slugs are kept without their views if categories nested in them are in slugs
+func (r MemoryRepository) GetCategoryConfigsIn(slugs map[string]struct{}) []domain.CategoryConfig {
+	return filterCategoryConfigs(r.GetCategoryConfigs(), slugs)
 }
 
-func (r Repository) GetCategoryConfigsIn(slugs map[string]struct{}) []domain.CategoryConfig {
+func filterCategoryConfigs(categoryConfigs []domain.CategoryConfig, slugs map[string]struct{}) []domain.CategoryConfig {
 	categories := []domain.CategoryConfig{}
-	for i := range r.GetCategoryConfigs() {
-		_, ok := slugs[r.opts.CategoryConfigs[i].Slug]
-		if !ok {
+	for i := range categoryConfigs {
+		category := categoryConfigs[i]
+		category.Categories = filterCategoryConfigs(category.Categories, slugs)
+
+		_, ok := slugs[category.Slug]
+		switch {
+		case ok:
+		case len(category.Categories) > 0:
+			category.Views = nil
+		default:
 			continue
 		}
 
-		categories = append(categories, r.opts.CategoryConfigs[i])
+		categories = append(categories, category)
 	}
 
 	return categories
 }
 
-func (r Repository) GetViewConfig(name string) (domain.ViewConfig, bool) {
-	for i := range r.opts.ViewConfigs {
-		if r.opts.ViewConfigs[i].Name == name {
-			return r.opts.ViewConfigs[i], true
+func (r MemoryRepository) GetViewConfig(name string) (domain.ViewConfig, bool) {
+	viewConfigs := r.GetViewConfigs()
+	for i := range viewConfigs {
+		if viewConfigs[i].Name == name {
+			return viewConfigs[i], true
+		}
+	}
+
+	return domain.ViewConfig{}, false
+}
+
+func (r MemoryRepository) GetViewConfigBySlug(slug string) (domain.ViewConfig, bool) {
+	viewConfigs := r.GetViewConfigs()
+	for i := range viewConfigs {
+		if viewConfigs[i].Slug == slug {
+			return viewConfigs[i], true
 		}
 	}
 
 	return domain.ViewConfig{}, false
 }
 
-func (r Repository) GetCommandConfig(slug string) (domain.CommandConfig, bool) {
-	command, ok := r.opts.CommandConfigs[slug]
+func (r MemoryRepository) GetCommandConfigs() map[string]domain.CommandConfig {
+	return r.opts.Load().CommandConfigs
+}
+
+func (r MemoryRepository) GetCommandConfig(slug string) (domain.CommandConfig, bool) {
+	command, ok := r.opts.Load().CommandConfigs[slug]
 
 	return command, ok
 }
+
+func (r MemoryRepository) GetSequenceConfig(slug string) (domain.SequenceConfig, bool) {
+	sequence, ok := r.opts.Load().SequenceConfigs[slug]
+
+	return sequence, ok
+}
+
+func (r MemoryRepository) GetUserConfig(id string) (domain.UserConfig, bool) {
+	user, ok := r.opts.Load().UserConfigs[id]
+
+	return user, ok
+}
+
+// GetHeaderGroupConfigs returns the groups whose slug or header groups match any of names, names are the groups of a groups header
+func (r MemoryRepository) GetHeaderGroupConfigs(names []string) []domain.GroupConfig {
+	namesM := map[string]struct{}{}
+	for _, name := range names {
+		namesM[name] = struct{}{}
+	}
+
+	var groups []domain.GroupConfig
+	for _, group := range r.opts.Load().GroupConfigs {
+		_, ok := namesM[group.Slug]
+		for i := 0; !ok && i < len(group.HeaderGroups); i++ {
+			_, ok = namesM[group.HeaderGroups[i]]
+		}
+
+		if ok {
+			groups = append(groups, group)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Slug < groups[j].Slug
+	})
+
+	return groups
+}
+
+// GetSecretValues returns the values of the configured secrets by slug
+func (r MemoryRepository) GetSecretValues() map[string]string {
+	return r.opts.Load().SecretValues
+}
+
+// GetAPIToken returns the api token with the hash
+func (r MemoryRepository) GetAPIToken(hash string) (domain.APIToken, bool) {
+	token, ok := r.opts.Load().APITokens[hash]
+
+	return token, ok
+}
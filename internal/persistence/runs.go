@@ -0,0 +1,92 @@
+package persistence
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/ppwfx/shellpane/internal/domain"
+	"github.com/ppwfx/shellpane/internal/utils/errutil"
+)
+
+// RunRepository stores runs, e.g. runs that wait for approval
+type RunRepository interface {
+	CreateRun(run domain.Run) error
+	GetRun(id string) (domain.Run, bool)
+	GetRuns() []domain.Run
+	UpdateRun(id string, update func(run domain.Run) (domain.Run, error)) (domain.Run, error)
+}
+
+type MemoryRunRepository struct {
+	mu   *sync.Mutex
+	runs map[string]domain.Run
+}
+
+func NewMemoryRunRepository() MemoryRunRepository {
+	return MemoryRunRepository{
+		mu:   &sync.Mutex{},
+		runs: map[string]domain.Run{},
+	}
+}
+
+func (r MemoryRunRepository) CreateRun(run domain.Run) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.runs[run.ID]
+	if ok {
+		return errutil.AlreadyExists(errutil.Nil(), "Run", run.ID)
+	}
+
+	r.runs[run.ID] = run
+
+	return nil
+}
+
+func (r MemoryRunRepository) GetRun(id string) (domain.Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[id]
+
+	return run, ok
+}
+
+// GetRuns returns all runs, the most recently requested first
+func (r MemoryRunRepository) GetRuns() []domain.Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := []domain.Run{}
+	for _, run := range r.runs {
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].RequestedAt.After(runs[j].RequestedAt)
+	})
+
+	return runs
+}
+
+// UpdateRun applies update to the run while holding the lock,
+// so that concurrent updates, like two approvals of the same run, are serialized
+func (r MemoryRunRepository) UpdateRun(id string, update func(run domain.Run) (domain.Run, error)) (domain.Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[id]
+	if !ok {
+		return domain.Run{}, errutil.NotFound(errutil.Nil(), "Run", id)
+	}
+
+	run, err := update(run)
+	if err != nil {
+		return domain.Run{}, errors.Wrapf(err, "failed to update run id=%v", id)
+	}
+
+	r.runs[id] = run
+
+	return run, nil
+}
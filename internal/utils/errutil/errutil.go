@@ -19,6 +19,8 @@ const (
 
 	StatusAlreadyExists = "already exists"
 
+	StatusConflict = "conflict"
+
 	StatusUnknown = "unknown"
 
 	StatusUnauthorized = "unauthorized"
@@ -42,6 +44,7 @@ var httpStatusCodeByStatus = map[string]int{
 	StatusNotFound:                 http.StatusNotFound,
 	StatusFailedDelete:             http.StatusInternalServerError,
 	StatusAlreadyExists:            http.StatusConflict,
+	StatusConflict:                 http.StatusConflict,
 	StatusUnknown:                  http.StatusInternalServerError,
 	StatusUnexpected:               http.StatusInternalServerError,
 	StatusDecoding:                 http.StatusBadRequest,
@@ -163,6 +166,34 @@ func (e AlreadyExistsError) Status() string {
 	return StatusAlreadyExists
 }
 
+func Conflict(err error, entity string, id string) error {
+	validateError(err)
+	return ConflictError{Err: err, Entity: entity, ID: id}
+}
+
+type ConflictError struct {
+	StatusError
+	Err    error
+	Entity string
+	ID     string
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("Conflict: Entity: %v ID: %v err: %v", e.Entity, e.ID, e.Err.Error())
+}
+
+func (e ConflictError) PublicError() string {
+	return fmt.Sprintf("%v with ID %v was changed concurrently", e.Entity, e.ID)
+}
+
+func (e ConflictError) Unwrap() error {
+	return e.Err
+}
+
+func (e ConflictError) Status() string {
+	return StatusConflict
+}
+
 func Unknown(err error) error {
 	validateError(err)
 	return UnknownError{Err: err}
@@ -170,8 +170,11 @@ func (t *basicAuthRoundTripper) SetNext(n http.RoundTripper) {
 	t.next = n
 }
 
+// RoundTrip sets the basic auth credentials unless the request is authorized already, e.g. with a bearer token
 func (t basicAuthRoundTripper) RoundTrip(r *http.Request) (res *http.Response, err error) {
-	r.SetBasicAuth(t.config.Username, t.config.Password)
+	if r.Header.Get("Authorization") == "" {
+		r.SetBasicAuth(t.config.Username, t.config.Password)
+	}
 
 	return t.next.RoundTrip(r)
 }
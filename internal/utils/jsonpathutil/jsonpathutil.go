@@ -0,0 +1,112 @@
+package jsonpathutil
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Path is a parsed json path like $.items[0].metadata.name
+type Path struct {
+	raw      string
+	segments []segment
+}
+
+type segment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Parse parses a json path consisting of dot separated keys and bracketed array indices,
+// the leading $ is optional
+func Parse(raw string) (Path, error) {
+	p := Path{raw: raw}
+
+	rest := strings.TrimPrefix(strings.TrimSpace(raw), "$")
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+
+			key := rest[:end]
+			if key == "" {
+				return Path{}, errors.Errorf("empty key in path=%v", raw)
+			}
+
+			p.segments = append(p.segments, segment{key: key})
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return Path{}, errors.Errorf("unclosed bracket in path=%v", raw)
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return Path{}, errors.Errorf("invalid index=%v in path=%v", rest[1:end], raw)
+			}
+
+			p.segments = append(p.segments, segment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			if len(p.segments) != 0 {
+				return Path{}, errors.Errorf("unexpected character=%q in path=%v", rest[0], raw)
+			}
+
+			rest = "." + rest
+		}
+	}
+
+	return p, nil
+}
+
+// Lookup decodes the json document and returns the value at the path,
+// strings are returned as is and any other value json encoded
+func (p Path) Lookup(document []byte) (string, error) {
+	var v interface{}
+	err := json.Unmarshal(document, &v)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to json unmarshal document")
+	}
+
+	for _, s := range p.segments {
+		switch {
+		case s.isIndex:
+			a, ok := v.([]interface{})
+			if !ok || s.index >= len(a) {
+				return "", errors.Errorf("no index=%v in path=%v", s.index, p.raw)
+			}
+
+			v = a[s.index]
+		default:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return "", errors.Errorf("no key=%v in path=%v", s.key, p.raw)
+			}
+
+			v, ok = m[s.key]
+			if !ok {
+				return "", errors.Errorf("no key=%v in path=%v", s.key, p.raw)
+			}
+		}
+	}
+
+	s, ok := v.(string)
+	if ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to json marshal value")
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,276 @@
+package jwtutil
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKS is a json web key set
+type JWKS struct {
+	keys map[string]crypto.PublicKey
+}
+
+// ParseJWKS parses the signature keys of a json web key set, keys of unsupported types are skipped
+func ParseJWKS(b []byte) (JWKS, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err := json.Unmarshal(b, &set)
+	if err != nil {
+		return JWKS{}, errors.Wrapf(err, "failed to json unmarshal jwks")
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return JWKS{}, errors.Wrapf(err, "failed to parse key kid=%v", k.Kid)
+		}
+
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	if len(keys) == 0 {
+		return JWKS{}, errors.Errorf("jwks contains no signature keys")
+	}
+
+	return JWKS{keys: keys}, nil
+}
+
+// Key returns the key with the key id, a token without key id is verified with the only key of the set
+func (s JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok := s.keys[kid]
+	if ok {
+		return key, nil
+	}
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+
+	return nil, errors.Errorf("unknown kid=%v", kid)
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode n")
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode e")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported crv=%v", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode x")
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode y")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.Errorf("unsupported crv=%v", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode x")
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.Errorf("invalid ed25519 key length=%v", len(x))
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+const (
+	// remoteJWKSMaxAge is how long fetched keys are used before they are fetched again
+	remoteJWKSMaxAge = time.Hour
+	// remoteJWKSMinInterval limits how often unknown key ids cause a fetch, e.g. after the issuer rotated its keys
+	remoteJWKSMinInterval = time.Minute
+	// remoteJWKSFetchTimeout bounds a fetch, it doesn't depend on the request that caused the fetch
+	remoteJWKSFetchTimeout = 10 * time.Second
+)
+
+// RemoteJWKS fetches a json web key set from a url, e.g. the jwks_uri of an oidc provider, and caches it
+type RemoteJWKS struct {
+	url        string
+	httpClient *http.Client
+	// mu guards state, it is not held while fetching
+	mu    *sync.Mutex
+	state *remoteJWKSState
+}
+
+type remoteJWKSState struct {
+	jwks      JWKS
+	fetchedAt time.Time
+	fetchErr  error
+	// fetching is closed when the fetch in progress is done, it is nil if no fetch is in progress
+	fetching chan struct{}
+}
+
+func NewRemoteJWKS(url string, httpClient *http.Client) RemoteJWKS {
+	return RemoteJWKS{
+		url:        url,
+		httpClient: httpClient,
+		mu:         &sync.Mutex{},
+		state:      &remoteJWKSState{},
+	}
+}
+
+// Key returns the key with the key id, the set is fetched again if it is outdated or doesn't contain the key,
+// if fetching fails the previously fetched keys are used. Only callers that don't know the key wait for the fetch.
+func (s RemoteJWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	key, err := s.state.jwks.Key(ctx, kid)
+	age := time.Since(s.state.fetchedAt)
+	if (err == nil && age < remoteJWKSMaxAge) || (err != nil && age < remoteJWKSMinInterval && s.state.fetching == nil) {
+		s.mu.Unlock()
+
+		return key, err
+	}
+
+	fetching := s.state.fetching
+	if fetching == nil && age >= remoteJWKSMinInterval {
+		fetching = make(chan struct{})
+		s.state.fetching = fetching
+		s.state.fetchedAt = time.Now()
+
+		go s.fetch(fetching)
+	}
+	s.mu.Unlock()
+
+	if err == nil || fetching == nil {
+		return key, err
+	}
+
+	select {
+	case <-fetching:
+	case <-ctx.Done():
+		return nil, errors.Wrapf(ctx.Err(), "failed to wait for jwks url=%v", s.url)
+	}
+
+	s.mu.Lock()
+	jwks, fetchErr := s.state.jwks, s.state.fetchErr
+	s.mu.Unlock()
+
+	if fetchErr != nil && jwks.keys == nil {
+		return nil, errors.Wrapf(fetchErr, "failed to fetch jwks url=%v", s.url)
+	}
+
+	return jwks.Key(ctx, kid)
+}
+
+// fetch fetches the set and swaps it in, done is closed afterwards
+func (s RemoteJWKS) fetch(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteJWKSFetchTimeout)
+	defer cancel()
+
+	jwks, err := s.get(ctx)
+
+	s.mu.Lock()
+	if err == nil {
+		s.state.jwks = jwks
+	}
+	s.state.fetchErr = err
+	s.state.fetching = nil
+	s.mu.Unlock()
+
+	close(done)
+}
+
+func (s RemoteJWKS) get(ctx context.Context) (JWKS, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return JWKS{}, errors.Wrapf(err, "failed to create request")
+	}
+
+	rsp, err := s.httpClient.Do(req)
+	if err != nil {
+		return JWKS{}, errors.Wrapf(err, "failed to do request")
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return JWKS{}, errors.Errorf("unexpected status code=%v", rsp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return JWKS{}, errors.Wrapf(err, "failed to read body")
+	}
+
+	jwks, err := ParseJWKS(b)
+	if err != nil {
+		return JWKS{}, errors.Wrapf(err, "failed to parse jwks")
+	}
+
+	return jwks, nil
+}
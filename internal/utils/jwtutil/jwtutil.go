@@ -0,0 +1,302 @@
+package jwtutil
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Keys returns the public key a token was signed with by the key id of the token, kid may be empty
+type Keys interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+type VerifierConfig struct {
+	// Issuer is compared to the iss claim if it is set
+	Issuer string
+	// Audience has to be one of the aud claim if it is set
+	Audience string
+	// Leeway is the clock skew that is tolerated when checking exp and nbf
+	Leeway time.Duration
+}
+
+type VerifierOpts struct {
+	Config VerifierConfig
+	Keys   Keys
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Verifier verifies the signature and the registered claims of json web tokens
+type Verifier struct {
+	opts VerifierOpts
+}
+
+func NewVerifier(opts VerifierOpts) Verifier {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return Verifier{
+		opts: opts,
+	}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Claims are the claims of a verified token
+type Claims map[string]interface{}
+
+// String returns the claim if it is a string
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+
+	return s
+}
+
+// Strings returns the claim if it is a string or an array of strings, a string is split at commas and spaces
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	case []interface{}:
+		var values []string
+		for i := range v {
+			s, ok := v[i].(string)
+			if ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+// Verify returns the claims of the token if its signature is valid, it is not expired
+// and its issuer and audience match
+func (v Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.Errorf("token is not a jws compact serialization")
+	}
+
+	var h header
+	err := decodeSegment(parts[0], &h)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode signature")
+	}
+
+	key, err := v.opts.Keys.Key(ctx, h.Kid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get key kid=%v", h.Kid)
+	}
+
+	err = verifySignature(h.Alg, key, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify signature alg=%v kid=%v", h.Alg, h.Kid)
+	}
+
+	var claims Claims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode claims")
+	}
+
+	err = v.verifyClaims(claims)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify claims")
+	}
+
+	return claims, nil
+}
+
+func (v Verifier) verifyClaims(claims Claims) error {
+	now := v.opts.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.Errorf("exp claim is missing")
+	}
+
+	if now.After(time.Unix(int64(exp), 0).Add(v.opts.Config.Leeway)) {
+		return errors.Errorf("token expired at=%v", time.Unix(int64(exp), 0))
+	}
+
+	nbf, ok := claims["nbf"].(float64)
+	if ok && now.Add(v.opts.Config.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.Errorf("token is not valid before=%v", time.Unix(int64(nbf), 0))
+	}
+
+	if v.opts.Config.Issuer != "" && claims.String("iss") != v.opts.Config.Issuer {
+		return errors.Errorf("unexpected issuer=%v", claims.String("iss"))
+	}
+
+	if v.opts.Config.Audience != "" {
+		// aud is either a string or an array of strings
+		audiences := []string{claims.String("aud")}
+		if _, ok := claims["aud"].([]interface{}); ok {
+			audiences = claims.Strings("aud")
+		}
+
+		found := false
+		for _, aud := range audiences {
+			if aud == v.opts.Config.Audience {
+				found = true
+			}
+		}
+
+		if !found {
+			return errors.Errorf("unexpected audience=%v", claims["aud"])
+		}
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.Wrapf(err, "failed to base64 decode segment")
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to json unmarshal segment")
+	}
+
+	return nil
+}
+
+var algHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// verifySignature verifies the signature of signed with key, the algorithm has to match the type of the key,
+// so that e.g. a public rsa key can't be used as hmac secret
+func verifySignature(alg string, key crypto.PublicKey, signed []byte, signature []byte) error {
+	if alg == "EdDSA" {
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errors.Errorf("key is not an ed25519 key")
+		}
+
+		if !ed25519.Verify(k, signed, signature) {
+			return errors.Errorf("invalid signature")
+		}
+
+		return nil
+	}
+
+	hash, ok := algHashes[alg]
+	if !ok {
+		return errors.Errorf("unsupported alg=%v", alg)
+	}
+
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.Errorf("key is not an rsa key")
+		}
+
+		if alg[:2] == "PS" {
+			return rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+
+		return rsa.VerifyPKCS1v15(k, hash, digest, signature)
+	default:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.Errorf("key is not an ecdsa key")
+		}
+
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.Errorf("invalid signature length=%v", len(signature))
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.Errorf("invalid signature")
+		}
+
+		return nil
+	}
+}
+
+// StaticKey is a single key that verifies tokens regardless of their key id
+type StaticKey struct {
+	key crypto.PublicKey
+}
+
+// ParseStaticKey parses a pem encoded public key or certificate
+func ParseStaticKey(b []byte) (StaticKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return StaticKey{}, errors.Errorf("no pem block found")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return StaticKey{}, errors.Wrapf(err, "failed to parse certificate")
+		}
+
+		return StaticKey{key: cert.PublicKey}, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return StaticKey{}, errors.Wrapf(err, "failed to parse pkcs1 public key")
+		}
+
+		return StaticKey{key: key}, nil
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return StaticKey{}, errors.Wrapf(err, "failed to parse pkix public key")
+		}
+
+		return StaticKey{key: key}, nil
+	}
+}
+
+func (k StaticKey) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	return k.key, nil
+}
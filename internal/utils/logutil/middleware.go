@@ -3,6 +3,8 @@ package logutil
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,8 +27,9 @@ func WithLoggerValueMiddleware(l *zap.SugaredLogger) func(next http.Handler) htt
 	}
 }
 
-// LogRequestMiddleware defines a http middleware logs every requests
-func LogRequestMiddleware(next http.Handler) http.Handler {
+// LogRequestMiddleware defines a http middleware logs every requests,
+// values of query parameters that start with one of redactQueryPrefixes are masked
+func LogRequestMiddleware(next http.Handler, redactQueryPrefixes ...string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		l := MustLoggerValue(r.Context())
 
@@ -38,7 +41,7 @@ func LogRequestMiddleware(next http.Handler) http.Handler {
 
 		l = l.With(zap.Object("context.httpRequest", &logHTTPRequest{
 			Method:             r.Method,
-			URL:                r.URL.String(),
+			URL:                redactURL(r.URL, redactQueryPrefixes),
 			UserAgent:          r.UserAgent(),
 			Referrer:           r.Referer(),
 			RemoteIP:           r.RemoteAddr,
@@ -144,3 +147,23 @@ func (r *logHTTPRequest) MarshalLogObject(e zapcore.ObjectEncoder) error {
 
 	return nil
 }
+
+func redactURL(u *url.URL, redactQueryPrefixes []string) string {
+	if len(redactQueryPrefixes) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+
+	q := u.Query()
+	for k := range q {
+		for _, prefix := range redactQueryPrefixes {
+			if strings.HasPrefix(k, prefix) {
+				q[k] = []string{"***"}
+			}
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = q.Encode()
+
+	return redacted.String()
+}